@@ -0,0 +1,12 @@
+data "ravelin_gcp_bindings" "example" {
+  iam_path = "../internal/iam"
+  project  = "my-project"
+}
+
+resource "google_project_iam_binding" "standing_roles" {
+  for_each = data.ravelin_gcp_bindings.example.bindings["my-project"]
+
+  project = "my-project"
+  role    = each.key
+  members = each.value
+}
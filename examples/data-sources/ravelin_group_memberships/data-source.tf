@@ -0,0 +1,34 @@
+data "ravelin_group_memberships" "example" {
+  iam_path   = "../internal/iam"
+  transitive = true
+}
+
+data "google_cloud_identity_group_lookup" "groups" {
+  for_each = data.ravelin_group_memberships.example.memberships
+
+  group_key {
+    id = each.key
+  }
+}
+
+locals {
+  group_memberships = merge([
+    for group, members in data.ravelin_group_memberships.example.memberships : {
+      for member in members : "${group}/${member}" => { group = group, member = member }
+    }
+  ]...)
+}
+
+resource "google_cloud_identity_group_membership" "members" {
+  for_each = local.group_memberships
+
+  group = data.google_cloud_identity_group_lookup.groups[each.value.group].name
+
+  preferred_member_key {
+    id = each.value.member
+  }
+
+  roles {
+    name = "MEMBER"
+  }
+}
@@ -0,0 +1,43 @@
+data "ravelin_gsudo_bindings" "example" {
+  iam_path = "../internal/iam"
+  project  = "my-project"
+
+  condition {
+    title      = "gsudo-{{ .Role }}"
+    expression = "{{ timeWindow 7 20 \"Europe/London\" }}{{ with .Expires }} && {{ expiresAt . }}{{ end }}"
+  }
+}
+
+data "google_iam_policy" "project" {
+  binding {
+    role = "roles/editor"
+    members = [
+      "user:john.doe@email.com",
+    ]
+  }
+
+  // Add the bindings of the gsudo escalations of the project
+  dynamic "binding" {
+    for_each = data.ravelin_gsudo_bindings.example.bindings
+
+    content {
+      role    = binding.value.role
+      members = binding.value.members
+
+      dynamic "condition" {
+        for_each = binding.value.condition == null ? [] : [binding.value.condition]
+
+        content {
+          title       = condition.value.title
+          description = condition.value.description
+          expression  = condition.value.expression
+        }
+      }
+    }
+  }
+}
+
+resource "google_project_iam_policy" "project" {
+  project     = "my-project"
+  policy_data = data.google_iam_policy.project.policy_data
+}
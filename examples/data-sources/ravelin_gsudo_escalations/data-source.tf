@@ -8,4 +8,16 @@ locals {
 
 output "gsudo_escalations" {
   value = local.gsudo_escalations.escalations
-}
\ No newline at end of file
+}
+
+data "ravelin_gsudo_escalations" "prod_payments" {
+  iam_path = "../internal/iam"
+  project  = "prod-payments"
+}
+
+output "prod_payments_owners" {
+  value = data.ravelin_gsudo_escalations.prod_payments.by_project["prod-payments"]["roles/owner"]
+}
+output "prod_payments_approvers" {
+  value = data.ravelin_gsudo_escalations.prod_payments.escalation_details["john.doe@ravelin.com"]["prod-payments"].approvers
+}
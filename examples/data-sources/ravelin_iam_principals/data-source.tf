@@ -0,0 +1,15 @@
+data "ravelin_iam_principals" "payments_engineers" {
+  iam_path = "../internal/iam"
+  type     = "user"
+  team     = "payments"
+}
+
+output "payments_engineers" {
+  value = [for principal in data.ravelin_iam_principals.payments_engineers.principals : principal.email]
+}
+
+data "ravelin_iam_principals" "platform" {
+  iam_path    = "../internal/iam"
+  group       = "platform"
+  email_regex = "@ravelin\\.com$"
+}
@@ -8,4 +8,21 @@ locals {
 
 output "twingate_access" {
   value = local.twingate_access
+}
+
+data "twingate_users" "all" {}
+
+locals {
+  twingate_user_ids = { for user in data.twingate_users.all.users : user.email => user.id }
+}
+
+resource "twingate_group" "groups" {
+  for_each = data.ravelin_twingate_access.twingate_users.twingate_groups
+
+  name     = each.key
+  user_ids = [for email in each.value : local.twingate_user_ids[email]]
+}
+
+output "twingate_resource_groups" {
+  value = data.ravelin_twingate_access.twingate_users.twingate_resources
 }
\ No newline at end of file
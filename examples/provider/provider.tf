@@ -9,4 +9,12 @@ terraform {
 
 provider "ravelin" {
   project = "my-project"
+
+  iam_settings {
+    user_domain  = "example.com"
+    group_prefix = "gcp-"
+    domain_overrides = {
+      "users/contractors" = "contractors.example.com"
+    }
+  }
 }
\ No newline at end of file
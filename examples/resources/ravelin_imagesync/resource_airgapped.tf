@@ -0,0 +1,16 @@
+# Images transferred as `docker save` tarballs or OCI image layouts
+resource "ravelin_imagesync" "busybox" {
+  source      = "oci-layout:///mnt/transfer/busybox@sha256:9ae97d36d26566ff84e8893c64a6dc4fe8ca6d1144bf5b87b2b85a32def253c7"
+  destination = "europe-docker.pkg.dev/my-project/my-registry/busybox:1.36"
+}
+
+resource "ravelin_imagesync" "nginx" {
+  source      = "docker-archive:///mnt/transfer/nginx.tar"
+  destination = "europe-docker.pkg.dev/my-project/my-registry/nginx:1.27"
+}
+
+# Backup of a registry image to an OCI image layout directory
+resource "ravelin_imagesync" "busybox_backup" {
+  source      = "europe-docker.pkg.dev/my-project/my-registry/busybox:1.36"
+  destination = "oci-layout:///mnt/backups/busybox:1.36"
+}
@@ -0,0 +1,5 @@
+resource "ravelin_imagesync" "cert_manager_chart" {
+  source      = "quay.io/jetstack/charts/cert-manager:v1.17.2"
+  destination = "europe-docker.pkg.dev/my-project/my-registry/charts/cert-manager:v1.17.2"
+  artifact    = true
+}
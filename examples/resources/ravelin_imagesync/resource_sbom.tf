@@ -0,0 +1,7 @@
+resource "ravelin_imagesync" "nginx" {
+  source        = "registry.hub.docker.com/library/nginx:1.27"
+  destination   = "europe-docker.pkg.dev/my-project/my-registry/dockerhub/nginx:1.27"
+  kms_key_id    = google_kms_crypto_key.attestation.id
+  generate_sbom = true
+  sbom_format   = "cyclonedx-json"
+}
@@ -0,0 +1,9 @@
+resource "ravelin_imagesync" "nginx" {
+  source          = "registry.hub.docker.com/library/nginx:1.27.3"
+  destination     = "europe-docker.pkg.dev/my-project/my-registry/dockerhub/nginx:1.27.3"
+  additional_tags = ["1.27", "stable"]
+
+  # keep the last 3 mirrored digests as 1.27.3-previous-1, 1.27.3-previous-2
+  # and 1.27.3-previous-3
+  retain_previous = 3
+}
@@ -0,0 +1,12 @@
+# The database is exported ahead of time, e.g. with
+# curl -O https://osv-vulnerabilities.storage.googleapis.com/Debian/all.zip
+resource "ravelin_imagesync" "debian" {
+  source      = "registry.hub.docker.com/library/debian:12"
+  destination = "europe-docker.pkg.dev/my-project/my-registry/dockerhub/debian:12"
+
+  vulnerability_gate {
+    db_path            = "${path.module}/osv/Debian-all.zip"
+    severity_threshold = "critical"
+    allow              = ["CVE-2023-4911"]
+  }
+}
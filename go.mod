@@ -12,10 +12,16 @@ require (
 	github.com/sigstore/sigstore v1.10.4
 	github.com/sigstore/sigstore/pkg/signature/kms/gcp v1.10.4
 	github.com/stretchr/testify v1.11.1
+	golang.org/x/sync v0.20.0
 	google.golang.org/api v0.271.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
+require (
+	github.com/knqyf263/go-rpmdb v0.1.2-0.20260720080917-eb60160a4db8
+	golang.org/x/xerrors v0.0.0-20240903120638-7835f813f4da // indirect
+)
+
 require (
 	cloud.google.com/go v0.123.0 // indirect
 	cloud.google.com/go/auth v0.18.2 // indirect
@@ -173,7 +179,6 @@ require (
 	golang.org/x/mod v0.35.0 // indirect
 	golang.org/x/net v0.52.0 // indirect
 	golang.org/x/oauth2 v0.36.0 // indirect
-	golang.org/x/sync v0.20.0 // indirect
 	golang.org/x/sys v0.43.0 // indirect
 	golang.org/x/term v0.41.0 // indirect
 	golang.org/x/text v0.36.0 // indirect
@@ -0,0 +1,269 @@
+package image
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/match"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+const (
+	// OCILayoutScheme prefixes references to an image stored in an OCI image
+	// layout directory, e.g. `oci-layout:///mnt/usb/busybox@sha256:...`.
+	OCILayoutScheme = "oci-layout://"
+	// DockerArchiveScheme prefixes references to an image stored in a tarball
+	// produced by `docker save`, e.g. `docker-archive:///mnt/usb/busybox.tar`.
+	DockerArchiveScheme = "docker-archive://"
+
+	// refNameAnnotation is the OCI annotation used to name an image in a layout.
+	refNameAnnotation = "org.opencontainers.image.ref.name"
+)
+
+// localReference is a parsed reference to an image on the local filesystem.
+type localReference struct {
+	scheme string
+	path   string
+	tag    string
+	digest string
+}
+
+// IsLocalReference returns true if the reference points to an image stored on
+// the local filesystem rather than in a registry.
+func IsLocalReference(ref string) bool {
+	return strings.HasPrefix(ref, OCILayoutScheme) || strings.HasPrefix(ref, DockerArchiveScheme)
+}
+
+// parseLocalReference splits a local reference into its path and optional tag
+// or digest. The tag is only considered if the last colon appears after the last
+// path separator so that paths containing colons are left untouched.
+func parseLocalReference(ref string) (localReference, error) {
+	var l localReference
+	switch {
+	case strings.HasPrefix(ref, OCILayoutScheme):
+		l.scheme = OCILayoutScheme
+	case strings.HasPrefix(ref, DockerArchiveScheme):
+		l.scheme = DockerArchiveScheme
+	default:
+		return l, fmt.Errorf("unsupported local reference %q", ref)
+	}
+
+	l.path = strings.TrimPrefix(ref, l.scheme)
+	if at := strings.LastIndex(l.path, "@"); at != -1 {
+		l.digest = l.path[at+1:]
+		l.path = l.path[:at]
+		if _, err := v1.NewHash(l.digest); err != nil {
+			return l, fmt.Errorf("invalid digest in reference %q: %w", ref, err)
+		}
+	}
+	if colon := strings.LastIndex(l.path, ":"); colon != -1 && colon > strings.LastIndex(l.path, "/") {
+		l.tag = l.path[colon+1:]
+		l.path = l.path[:colon]
+	}
+
+	if l.path == "" {
+		return l, fmt.Errorf("missing path in reference %q", ref)
+	}
+	return l, nil
+}
+
+// GetImage returns the image for the given reference if it exists along with a
+// string representation of it's digest. The reference can either point to a
+// registry, an OCI layout directory or a docker archive. Digests of local
+// images are computed locally. The digest of a reference to a multi-platform
+// index of a layout is checked against the index, the returned image and digest
+// being the ones of the default platform.
+func GetImage(ref string, auth authn.Authenticator) (v1.Image, bool, string, error) {
+	if !IsLocalReference(ref) {
+		return GetRemoteImage(ref, auth)
+	}
+
+	l, err := parseLocalReference(ref)
+	if err != nil {
+		return empty.Image, false, "", err
+	}
+
+	var img v1.Image
+	var matched v1.Hash
+	switch l.scheme {
+	case OCILayoutScheme:
+		img, matched, err = l.layoutImage()
+	case DockerArchiveScheme:
+		img, err = l.archiveImage()
+	}
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return empty.Image, false, "", nil
+		}
+		return empty.Image, false, "", err
+	}
+
+	imgDigest, err := img.Digest()
+	if err != nil {
+		return empty.Image, true, "", err
+	}
+
+	if matched == (v1.Hash{}) {
+		matched = imgDigest
+	}
+	if l.digest != "" && l.digest != matched.String() {
+		return empty.Image, false, "", fmt.Errorf("image in %s has digest %s, expected %s", l.path, matched, l.digest)
+	}
+
+	return img, true, imgDigest.String(), nil
+}
+
+// layoutImage finds the image in the OCI layout matching the digest or tag of
+// the reference. Without either, the layout must contain a single image. It
+// also returns the digest of the matched descriptor, which is the one of the
+// index for multi-platform images.
+func (l localReference) layoutImage() (v1.Image, v1.Hash, error) {
+	idx, err := layout.ImageIndexFromPath(l.path)
+	if err != nil {
+		return nil, v1.Hash{}, err
+	}
+
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, v1.Hash{}, fmt.Errorf("error reading index of %s: %w", l.path, err)
+	}
+
+	var candidates []v1.Descriptor
+	for _, desc := range manifest.Manifests {
+		switch {
+		case l.digest != "" && desc.Digest.String() == l.digest:
+			candidates = append(candidates, desc)
+		case l.digest == "" && l.tag != "" && desc.Annotations[refNameAnnotation] == l.tag:
+			candidates = append(candidates, desc)
+		case l.digest == "" && l.tag == "":
+			candidates = append(candidates, desc)
+		}
+	}
+
+	switch {
+	case len(candidates) == 0:
+		return nil, v1.Hash{}, os.ErrNotExist
+	case len(candidates) > 1:
+		return nil, v1.Hash{}, fmt.Errorf("layout %s contains %d images, please reference one by digest", l.path, len(candidates))
+	}
+
+	desc := candidates[0]
+	if !desc.MediaType.IsIndex() {
+		img, err := idx.Image(desc.Digest)
+		return img, desc.Digest, err
+	}
+
+	// multi-platform images are resolved to the default platform, the same way
+	// remote.Image does for registry references
+	child, err := idx.ImageIndex(desc.Digest)
+	if err != nil {
+		return nil, v1.Hash{}, err
+	}
+	children, err := child.IndexManifest()
+	if err != nil {
+		return nil, v1.Hash{}, err
+	}
+	defaultPlatform := v1.Platform{OS: "linux", Architecture: "amd64"}
+	for _, c := range children.Manifests {
+		if c.Platform != nil && c.Platform.Satisfies(defaultPlatform) {
+			img, err := child.Image(c.Digest)
+			return img, desc.Digest, err
+		}
+	}
+	return nil, v1.Hash{}, fmt.Errorf("no image for platform %s found in %s", defaultPlatform, l.path)
+}
+
+// archiveImage loads the image from a docker archive. Archives holding more
+// than one image are not supported.
+func (l localReference) archiveImage() (v1.Image, error) {
+	if l.tag != "" {
+		return nil, fmt.Errorf("tags are not supported for %s references, the archive must contain a single image", DockerArchiveScheme)
+	}
+	if _, err := os.Stat(l.path); err != nil {
+		return nil, err
+	}
+
+	return tarball.ImageFromPath(l.path, nil)
+}
+
+// WriteImage writes the image to the given destination reference. Registry
+// destinations are written with the given authenticator, OCI layout
+// destinations are created if they don't exist yet.
+func WriteImage(ref string, img v1.Image, auth authn.Authenticator) error {
+	if !IsLocalReference(ref) {
+		destRef, err := name.ParseReference(ref, name.WeakValidation)
+		if err != nil {
+			return fmt.Errorf("failed to parse destination reference: %w", err)
+		}
+		return remote.Write(destRef, img, remote.WithAuth(auth))
+	}
+
+	l, err := parseLocalReference(ref)
+	if err != nil {
+		return err
+	}
+	if l.scheme != OCILayoutScheme {
+		return fmt.Errorf("only %s destinations are supported for local images", OCILayoutScheme)
+	}
+
+	p, err := layout.FromPath(l.path)
+	if err != nil {
+		p, err = layout.Write(l.path, empty.Index)
+		if err != nil {
+			return fmt.Errorf("error creating OCI layout %s: %w", l.path, err)
+		}
+	}
+
+	var opts []layout.Option
+	if l.tag != "" {
+		opts = append(opts, layout.WithAnnotations(map[string]string{refNameAnnotation: l.tag}))
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		return err
+	}
+
+	// replacing rather than appending keeps the layout index free of duplicates
+	// when the same image, or a new image under the same tag, is exported again
+	matcher := match.Digests(digest)
+	if l.tag != "" {
+		matcher = func(desc v1.Descriptor) bool {
+			return desc.Digest == digest || desc.Annotations[refNameAnnotation] == l.tag
+		}
+	}
+	return p.ReplaceImage(img, matcher, opts...)
+}
+
+// DeleteLayoutImage removes the image with the given digest from the index of
+// an OCI layout. Blobs are left in place as other images of the layout might
+// share them.
+func DeleteLayoutImage(ref string, digest string) error {
+	l, err := parseLocalReference(ref)
+	if err != nil {
+		return err
+	}
+
+	hash, err := v1.NewHash(digest)
+	if err != nil {
+		return err
+	}
+
+	p, err := layout.FromPath(l.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	return p.RemoveDescriptors(match.Digests(hash))
+}
@@ -0,0 +1,149 @@
+package image
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocalReference(t *testing.T) {
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		ref      string
+		expected localReference
+		expError bool
+	}{
+		{
+			ref:      "oci-layout:///mnt/usb/busybox",
+			expected: localReference{scheme: OCILayoutScheme, path: "/mnt/usb/busybox"},
+		},
+		{
+			ref:      "oci-layout:///mnt/usb/busybox:1.36",
+			expected: localReference{scheme: OCILayoutScheme, path: "/mnt/usb/busybox", tag: "1.36"},
+		},
+		{
+			ref:      "oci-layout:///mnt/usb/busybox@" + digest,
+			expected: localReference{scheme: OCILayoutScheme, path: "/mnt/usb/busybox", digest: digest},
+		},
+		{
+			ref:      "docker-archive:///mnt/usb:transfer/busybox.tar",
+			expected: localReference{scheme: DockerArchiveScheme, path: "/mnt/usb:transfer/busybox.tar"},
+		},
+		{ref: "oci-layout:///mnt/usb/busybox@sha256:invalid", expError: true},
+		{ref: "oci-layout://", expError: true},
+		{ref: "registry.hub.docker.com/library/busybox", expError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			out, err := parseLocalReference(tt.ref)
+			if tt.expError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, out)
+		})
+	}
+}
+
+func TestLocalImages(t *testing.T) {
+	img, err := random.Image(512, 2)
+	require.NoError(t, err)
+	digest, err := img.Digest()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+
+	// docker save tarballs are read with a locally computed digest
+	archive := filepath.Join(dir, "busybox.tar")
+	tag, err := name.NewTag("busybox:latest")
+	require.NoError(t, err)
+	require.NoError(t, tarball.WriteToFile(archive, tag, img))
+
+	_, exists, archiveDigest, err := GetImage(DockerArchiveScheme+archive, authn.Anonymous)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	// exporting to a layout creates it and tags the image
+	layoutRef := OCILayoutScheme + filepath.Join(dir, "layout")
+	require.NoError(t, WriteImage(layoutRef+":latest", img, authn.Anonymous))
+
+	_, exists, layoutDigest, err := GetImage(layoutRef+"@"+digest.String(), authn.Anonymous)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, digest.String(), layoutDigest)
+
+	_, exists, _, err = GetImage(layoutRef+":latest", authn.Anonymous)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	id, err := ImageID(layoutRef+":latest", img)
+	require.NoError(t, err)
+	require.Equal(t, layoutRef+"@"+digest.String(), id)
+
+	// the archive digest is computed from the loaded config and layers, the
+	// image can be written to a layout and read back with the same digest
+	archiveImg, _, _, err := GetImage(DockerArchiveScheme+archive, authn.Anonymous)
+	require.NoError(t, err)
+	require.NoError(t, WriteImage(layoutRef+":archive", archiveImg, authn.Anonymous))
+	_, exists, fromLayout, err := GetImage(layoutRef+":archive", authn.Anonymous)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, archiveDigest, fromLayout)
+
+	require.NoError(t, DeleteLayoutImage(layoutRef, digest.String()))
+	_, exists, _, err = GetImage(layoutRef+"@"+digest.String(), authn.Anonymous)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	_, exists, _, err = GetImage(DockerArchiveScheme+filepath.Join(dir, "missing.tar"), authn.Anonymous)
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestLocalMultiPlatformImage(t *testing.T) {
+	amd64, err := random.Image(512, 2)
+	require.NoError(t, err)
+	arm64, err := random.Image(512, 2)
+	require.NoError(t, err)
+	amd64Digest, err := amd64.Digest()
+	require.NoError(t, err)
+
+	idx := mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{Add: arm64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}}},
+		mutate.IndexAddendum{Add: amd64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}}},
+	)
+	idxDigest, err := idx.Digest()
+	require.NoError(t, err)
+
+	dir := filepath.Join(t.TempDir(), "layout")
+	p, err := layout.Write(dir, empty.Index)
+	require.NoError(t, err)
+	require.NoError(t, p.AppendIndex(idx))
+
+	// the digest of the reference is the one of the index, the image is
+	// resolved to the default platform
+	img, exists, digest, err := GetImage(OCILayoutScheme+dir+"@"+idxDigest.String(), authn.Anonymous)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, amd64Digest.String(), digest)
+	imgDigest, err := img.Digest()
+	require.NoError(t, err)
+	require.Equal(t, amd64Digest, imgDigest)
+
+	// platform images are not referenced by the layout index
+	_, exists, _, err = GetImage(OCILayoutScheme+dir+"@"+amd64Digest.String(), authn.Anonymous)
+	require.NoError(t, err)
+	require.False(t, exists)
+}
@@ -1,7 +1,6 @@
 package image
 
 import (
-	"regexp"
 	"strings"
 
 	"github.com/google/go-containerregistry/pkg/authn"
@@ -12,6 +11,34 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 )
 
+// GetRemoteDescriptor returns the remote descriptor of any OCI artifact (image,
+// index or arbitrary manifest) if it exists along with a string representation
+// of it's digest. Unlike GetRemoteImage, the descriptor is never resolved to a
+// platform specific image, so custom media types are preserved.
+func GetRemoteDescriptor(url string, auth authn.Authenticator) (*remote.Descriptor, bool, string, error) {
+	urlRef, err := name.ParseReference(url, name.WeakValidation)
+	if err != nil {
+		return nil, false, "", err
+	}
+
+	desc, err := remote.Get(urlRef, remote.WithAuth(auth))
+	if err != nil {
+		if tErr, ok := (err).(*transport.Error); ok && tErr.StatusCode == 404 {
+			return nil, false, "", nil
+		}
+		return nil, false, "", err
+	}
+
+	return desc, true, desc.Digest.String(), nil
+}
+
+// CopyArtifact pushes the given descriptor to the destination reference. The
+// manifest is pushed as is, along with all the blobs and child manifests it
+// references, so the digest of the artifact is preserved.
+func CopyArtifact(desc *remote.Descriptor, dest name.Reference, auth authn.Authenticator) error {
+	return remote.Push(dest, desc, remote.WithAuth(auth))
+}
+
 // getRemoteImage returns a remote image if it exists along with a string
 // representation of it's digest
 func GetRemoteImage(url string, auth authn.Authenticator) (v1.Image, bool, string, error) {
@@ -36,17 +63,28 @@ func GetRemoteImage(url string, auth authn.Authenticator) (v1.Image, bool, strin
 	return i, true, imgDigest.String(), nil
 }
 
-// imageID is the fully qualified URL to the image, with any tags replaced with
-// the sha256 digest instead
+// ImageID is the fully qualified URL to the image, with any tags replaced with
+// the sha256 digest instead. Registry references are parsed so that the port
+// of the registry is never mistaken for a tag.
 func ImageID(url string, img v1.Image) (string, error) {
-	if hasSHA, _ := regexp.MatchString("(.+)(@sha256:)([a-f0-9]{64})", url); hasSHA {
-		return url, nil
-	}
-
-	// Trim any tags from the url
-	trimTo := strings.LastIndex(url, ":")
-	if trimTo != -1 && trimTo < len(url) {
-		url = url[:trimTo]
+	if IsLocalReference(url) {
+		l, err := parseLocalReference(url)
+		if err != nil {
+			return "", err
+		}
+		if l.digest != "" {
+			return url, nil
+		}
+		url = l.scheme + l.path
+	} else {
+		ref, err := name.ParseReference(url, name.WeakValidation)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := ref.(name.Digest); ok {
+			return url, nil
+		}
+		url = ref.Context().Name()
 	}
 
 	digest, err := img.Digest()
@@ -57,6 +95,21 @@ func ImageID(url string, img v1.Image) (string, error) {
 	return url + "@" + digest.String(), nil
 }
 
+// ArtifactID is the fully qualified URL to the artifact, with any tags replaced
+// with the sha256 digest of the descriptor instead. The reference is parsed so
+// that the port of the registry is never mistaken for a tag.
+func ArtifactID(url string, desc *remote.Descriptor) (string, error) {
+	ref, err := name.ParseReference(url, name.WeakValidation)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := ref.(name.Digest); ok {
+		return url, nil
+	}
+
+	return ref.Context().Name() + "@" + desc.Digest.String(), nil
+}
+
 // digestFromReference strips all content preceding the digest for the given,
 // fully qualified, reference. If no digest is present, the resulting string
 // will be empty
@@ -0,0 +1,123 @@
+package image
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/require"
+)
+
+// helmChart returns an OCI artifact shaped like a Helm chart: a custom config
+// media type, a single chart layer and a manifest annotation.
+func helmChart(t *testing.T) v1.Image {
+	t.Helper()
+
+	chart := static.NewLayer([]byte("chart-content"), "application/vnd.cncf.helm.chart.content.v1.tar+gzip")
+
+	img, err := mutate.Append(empty.Image, mutate.Addendum{Layer: chart})
+	require.NoError(t, err)
+
+	img = mutate.MediaType(img, types.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, "application/vnd.cncf.helm.config.v1+json")
+	return mutate.Annotations(img, map[string]string{"org.opencontainers.image.title": "my-chart"}).(v1.Image)
+}
+
+func TestCopyArtifact(t *testing.T) {
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+
+	chart := helmChart(t)
+	srcRef, err := name.ParseReference(addr+"/charts/my-chart:1.0.0", name.Insecure)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(srcRef, chart))
+
+	srcDesc, exists, srcDigest, err := GetRemoteDescriptor(srcRef.String(), authn.Anonymous)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	destRef, err := name.ParseReference(addr+"/mirror/my-chart:1.0.0", name.Insecure)
+	require.NoError(t, err)
+	require.NoError(t, CopyArtifact(srcDesc, destRef, authn.Anonymous))
+
+	destDesc, exists, destDigest, err := GetRemoteDescriptor(destRef.String(), authn.Anonymous)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, srcDigest, destDigest)
+	require.Equal(t, types.OCIManifestSchema1, destDesc.MediaType)
+
+	manifest, err := v1.ParseManifest(strings.NewReader(string(destDesc.Manifest)))
+	require.NoError(t, err)
+	require.Equal(t, types.MediaType("application/vnd.cncf.helm.config.v1+json"), manifest.Config.MediaType)
+	require.Equal(t, "my-chart", manifest.Annotations["org.opencontainers.image.title"])
+
+	id, err := ArtifactID(destRef.String(), destDesc)
+	require.NoError(t, err)
+	require.Equal(t, addr+"/mirror/my-chart@"+destDigest, id)
+
+	// the port of the registry is kept when the reference has no tag
+	id, err = ArtifactID(addr+"/mirror/my-chart", destDesc)
+	require.NoError(t, err)
+	require.Equal(t, addr+"/mirror/my-chart@"+destDigest, id)
+}
+
+func TestGetRemoteDescriptorNotFound(t *testing.T) {
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+
+	_, exists, _, err := GetRemoteDescriptor(addr+"/charts/missing:1.0.0", authn.Anonymous)
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestImageID(t *testing.T) {
+	img := empty.Image
+	digest, err := img.Digest()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{
+			name:     "tag",
+			url:      "europe-docker.pkg.dev/my-project/mirror/busybox:1.36",
+			expected: "europe-docker.pkg.dev/my-project/mirror/busybox@" + digest.String(),
+		},
+		{
+			name:     "registry_port_with_tag",
+			url:      "localhost:5000/mirror/busybox:1.36",
+			expected: "localhost:5000/mirror/busybox@" + digest.String(),
+		},
+		{
+			name:     "registry_port_without_tag",
+			url:      "localhost:5000/mirror/busybox",
+			expected: "localhost:5000/mirror/busybox@" + digest.String(),
+		},
+		{
+			name:     "digest",
+			url:      "localhost:5000/mirror/busybox@" + digest.String(),
+			expected: "localhost:5000/mirror/busybox@" + digest.String(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := ImageID(tt.url, img)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, id)
+		})
+	}
+}
@@ -33,9 +33,31 @@ func SignImage(ctx context.Context, digestRef name.Digest, kmsRef string, auth a
 	return signImage(ctx, digestRef, sv, auth)
 }
 
+// AttestImage loads the GCP KMS signer and attaches a signed attestation with
+// the given predicate to the image. The predicate must be a JSON object.
+func AttestImage(ctx context.Context, digestRef name.Digest, kmsRef, predicateType string, predicate []byte, auth authn.Authenticator) error {
+	sv, err := sigs.SignerVerifierFromKeyRef(ctx, "gcpkms://"+kmsRef, nil, nil)
+	if err != nil {
+		return fmt.Errorf("load KMS signer: %w", err)
+	}
+
+	var pred structpb.Struct
+	if err := protojson.Unmarshal(predicate, &pred); err != nil {
+		return fmt.Errorf("unmarshal predicate: %w", err)
+	}
+	return attestImage(ctx, digestRef, sv, auth, predicateType, &pred)
+}
+
 // signImage is the testable core: it signs digestRef using the provided signer
 // and pushes the OCI signature to the registry via the referrers API.
 func signImage(ctx context.Context, digestRef name.Digest, sv sigsig.Signer, auth authn.Authenticator) error {
+	return attestImage(ctx, digestRef, sv, auth, types.CosignSignPredicateType, &structpb.Struct{})
+}
+
+// attestImage wraps the predicate in an in-toto statement about digestRef,
+// signs it using the provided signer and pushes the resulting bundle to the
+// registry via the referrers API.
+func attestImage(ctx context.Context, digestRef name.Digest, sv sigsig.Signer, auth authn.Authenticator, predicateType string, predicate *structpb.Struct) error {
 	digestParts := strings.Split(digestRef.DigestStr(), ":")
 	if len(digestParts) != 2 {
 		return fmt.Errorf("unable to parse digest %s", digestRef.DigestStr())
@@ -45,8 +67,8 @@ func signImage(ctx context.Context, digestRef name.Digest, sv sigsig.Signer, aut
 		Subject: []*intotov1.ResourceDescriptor{{
 			Digest: map[string]string{digestParts[0]: digestParts[1]},
 		}},
-		PredicateType: types.CosignSignPredicateType,
-		Predicate:     &structpb.Struct{},
+		PredicateType: predicateType,
+		Predicate:     predicate,
 	}
 	payload, err := protojson.Marshal(statement)
 	if err != nil {
@@ -76,7 +98,7 @@ func signImage(ctx context.Context, digestRef name.Digest, sv sigsig.Signer, aut
 	}
 
 	remoteOpt := ociremote.WithRemoteOptions(remote.WithAuth(auth))
-	if err := ociremote.WriteAttestationNewBundleFormat(digestRef, bundleBytes, types.CosignSignPredicateType, remoteOpt); err != nil {
+	if err := ociremote.WriteAttestationNewBundleFormat(digestRef, bundleBytes, predicateType, remoteOpt); err != nil {
 		return fmt.Errorf("push bundle: %w", err)
 	}
 
@@ -17,6 +17,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	sigsig "github.com/sigstore/sigstore/pkg/signature"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 // pushRandomImage pushes a random image to the registry at addr and returns its
@@ -61,3 +62,17 @@ func TestSignImage_Success(t *testing.T) {
 	err := signImage(context.Background(), digestRef, ecdsaSigner(t), authn.Anonymous)
 	require.NoError(t, err)
 }
+
+func TestAttestImage_SBOMPredicate(t *testing.T) {
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+
+	addr := strings.TrimPrefix(srv.URL, "http://")
+	digestRef := pushRandomImage(t, addr)
+
+	predicate, err := structpb.NewStruct(map[string]any{"spdxVersion": "SPDX-2.3", "packages": []any{}})
+	require.NoError(t, err)
+
+	err = attestImage(context.Background(), digestRef, ecdsaSigner(t), authn.Anonymous, "https://spdx.dev/Document", predicate)
+	require.NoError(t, err)
+}
@@ -0,0 +1,172 @@
+package image
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// previousTagInfix joins the destination tag and the position of the digests
+// previously mirrored to it, e.g. `1.27-previous-1` being the most recent
+// digest replaced under the `1.27` tag.
+const previousTagInfix = "-previous-"
+
+// PreviousTag returns the tag used to retain the n-th previous digest of the
+// destination tag.
+func PreviousTag(tag string, n int) string {
+	return tag + previousTagInfix + strconv.Itoa(n)
+}
+
+// TagImage adds the given tags to the manifest referenced by digestRef.
+func TagImage(digestRef name.Digest, tags []string, auth authn.Authenticator) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	desc, err := remote.Get(digestRef, remote.WithAuth(auth))
+	if err != nil {
+		return fmt.Errorf("error getting %s: %w", digestRef, err)
+	}
+
+	for _, tag := range tags {
+		if err := remote.Tag(digestRef.Context().Tag(tag), desc, remote.WithAuth(auth)); err != nil {
+			return fmt.Errorf("error tagging %s with %s: %w", digestRef, tag, err)
+		}
+	}
+	return nil
+}
+
+// UntagImage removes the given tags from the repository. Tags that don't exist
+// are ignored.
+func UntagImage(repo name.Repository, tags []string, auth authn.Authenticator) error {
+	for _, tag := range tags {
+		if err := remote.Delete(repo.Tag(tag), remote.WithAuth(auth)); err != nil && !isNotFound(err) {
+			return fmt.Errorf("error deleting tag %s: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// RetainPrevious keeps digestRef in the history of the destination tag by
+// tagging it `<tag>-previous-1`. Existing history tags are shifted by one and
+// the ones beyond the retention count are removed. The history of other tags of
+// the repository is left untouched. Retaining the most recent digest of the
+// history again only prunes it, so that a failed update can be retried.
+func RetainPrevious(digestRef name.Digest, dest name.Tag, retain int, auth authn.Authenticator) error {
+	repo := dest.Context()
+
+	history, err := previousTags(dest, auth)
+	if err != nil {
+		return err
+	}
+	if history[1] == digestRef.DigestStr() {
+		return PrunePrevious(dest, retain, auth)
+	}
+
+	// shift from the oldest entry so that no tag is overwritten before being moved
+	for n := retain - 1; n >= 1; n-- {
+		digest, ok := history[n]
+		if !ok {
+			continue
+		}
+		prevRef := repo.Digest(digest)
+		if err := TagImage(prevRef, []string{PreviousTag(dest.TagStr(), n+1)}, auth); err != nil {
+			return err
+		}
+	}
+
+	if err := TagImage(repo.Digest(digestRef.DigestStr()), []string{PreviousTag(dest.TagStr(), 1)}, auth); err != nil {
+		return err
+	}
+
+	return PrunePrevious(dest, retain, auth)
+}
+
+// PrunePrevious removes the history tags of the destination tag beyond the
+// retention count, all of them with a count of 0.
+func PrunePrevious(dest name.Tag, retain int, auth authn.Authenticator) error {
+	history, err := previousTags(dest, auth)
+	if err != nil {
+		return err
+	}
+
+	var expired []string
+	for n := range history {
+		if n > retain {
+			expired = append(expired, PreviousTag(dest.TagStr(), n))
+		}
+	}
+	return UntagImage(dest.Context(), expired, auth)
+}
+
+// PreviousDigests returns the fully qualified digest references retained in the
+// history of the destination tag, most recent first.
+func PreviousDigests(dest name.Tag, retain int, auth authn.Authenticator) ([]string, error) {
+	history, err := previousTags(dest, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]int, 0, len(history))
+	for n := range history {
+		if n <= retain {
+			positions = append(positions, n)
+		}
+	}
+	sort.Ints(positions)
+
+	digests := make([]string, 0, len(positions))
+	for _, n := range positions {
+		digests = append(digests, dest.Context().String()+"@"+history[n])
+	}
+	return digests, nil
+}
+
+// previousTags returns the digests referenced by the history tags of the
+// destination tag keyed by their position.
+func previousTags(dest name.Tag, auth authn.Authenticator) (map[int]string, error) {
+	repo := dest.Context()
+	tags, err := remote.List(repo, remote.WithAuth(auth))
+	if err != nil {
+		if isNotFound(err) {
+			return map[int]string{}, nil
+		}
+		return nil, fmt.Errorf("error listing tags of %s: %w", repo, err)
+	}
+
+	prefix := dest.TagStr() + previousTagInfix
+	history := make(map[int]string)
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, prefix) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(tag, prefix))
+		if err != nil || n < 1 {
+			continue
+		}
+
+		desc, err := remote.Head(repo.Tag(tag), remote.WithAuth(auth))
+		if err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error getting tag %s: %w", tag, err)
+		}
+		history[n] = desc.Digest.String()
+	}
+	return history, nil
+}
+
+// isNotFound returns true if the registry returned a 404 error.
+func isNotFound(err error) bool {
+	var tErr *transport.Error
+	return errors.As(err, &tErr) && tErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,88 @@
+package image
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagImage(t *testing.T) {
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+
+	digestRef := pushRandomImage(t, addr)
+	require.NoError(t, TagImage(digestRef, []string{"1.27.3", "1.27", "stable"}, authn.Anonymous))
+
+	tags, err := remote.List(digestRef.Context())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"latest", "1.27.3", "1.27", "stable"}, tags)
+
+	require.NoError(t, UntagImage(digestRef.Context(), []string{"1.27", "missing"}, authn.Anonymous))
+
+	tags, err = remote.List(digestRef.Context())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"latest", "1.27.3", "stable"}, tags)
+}
+
+func TestRetainPrevious(t *testing.T) {
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+
+	// every push moves the latest tag to a new digest
+	var digests []name.Digest
+	for range 4 {
+		digests = append(digests, pushRandomImage(t, addr))
+	}
+	repo := digests[0].Context()
+	latest := repo.Tag("latest")
+	stable := repo.Tag("stable")
+
+	for _, d := range digests {
+		require.NoError(t, RetainPrevious(d, latest, 2, authn.Anonymous))
+	}
+	require.NoError(t, RetainPrevious(digests[0], stable, 2, authn.Anonymous))
+
+	// retaining the most recent digest again, e.g. when an update is retried,
+	// doesn't shift the history
+	require.NoError(t, RetainPrevious(digests[3], latest, 2, authn.Anonymous))
+
+	history, err := PreviousDigests(latest, 2, authn.Anonymous)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		repo.String() + "@" + digests[3].DigestStr(),
+		repo.String() + "@" + digests[2].DigestStr(),
+	}, history)
+
+	// the history is scoped to the destination tag
+	history, err = PreviousDigests(stable, 2, authn.Anonymous)
+	require.NoError(t, err)
+	require.Equal(t, []string{repo.String() + "@" + digests[0].DigestStr()}, history)
+
+	tags, err := remote.List(repo)
+	require.NoError(t, err)
+	require.Contains(t, tags, "latest-previous-2")
+	require.NotContains(t, tags, PreviousTag("latest", 3))
+
+	// reducing the retention prunes the oldest entries
+	require.NoError(t, PrunePrevious(latest, 1, authn.Anonymous))
+	history, err = PreviousDigests(latest, 1, authn.Anonymous)
+	require.NoError(t, err)
+	require.Equal(t, []string{repo.String() + "@" + digests[3].DigestStr()}, history)
+
+	// without retention the whole history of the tag is removed
+	require.NoError(t, PrunePrevious(latest, 0, authn.Anonymous))
+	history, err = PreviousDigests(latest, 2, authn.Anonymous)
+	require.NoError(t, err)
+	require.Empty(t, history)
+	history, err = PreviousDigests(stable, 2, authn.Anonymous)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+}
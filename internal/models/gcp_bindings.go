@@ -0,0 +1,12 @@
+package models
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type GcpBindingsDataSourceModel struct {
+	IamPath  types.String `tfsdk:"iam_path"`
+	Project  types.String `tfsdk:"project"`  // optional filter for project
+	Bindings types.Map    `tfsdk:"bindings"` // map of projects to roles to members
+	Id       types.String `tfsdk:"id"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type GroupMembershipsDataSourceModel struct {
+	IamPath     types.String `tfsdk:"iam_path"`
+	Memberships types.Map    `tfsdk:"memberships"` // map of group emails to member emails
+	Id          types.String `tfsdk:"id"`
+
+	IncludeServiceAccounts types.Bool `tfsdk:"include_service_accounts"`
+	Transitive             types.Bool `tfsdk:"transitive"`
+	IncludeInactive        types.Bool `tfsdk:"include_inactive"`
+	Inactive               types.Map  `tfsdk:"inactive"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type GsudoBindingsDataSourceModel struct {
+	IamPath   types.String                         `tfsdk:"iam_path"`
+	Project   types.String                         `tfsdk:"project"`
+	Condition *GsudoBindingsConditionTemplateModel `tfsdk:"condition"`
+	Bindings  types.List                           `tfsdk:"bindings"` // list of ConditionalBindingModel
+	Id        types.String                         `tfsdk:"id"`
+
+	IncludeServiceAccounts types.Bool `tfsdk:"include_service_accounts"`
+}
+
+// GsudoBindingsConditionTemplateModel holds the text/template templates the
+// conditions of the bindings are rendered from.
+type GsudoBindingsConditionTemplateModel struct {
+	Title       types.String `tfsdk:"title"`
+	Description types.String `tfsdk:"description"`
+	Expression  types.String `tfsdk:"expression"`
+}
@@ -1,13 +1,41 @@
 package models
 
 import (
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 type GsudoEscalationsDataSourceModel struct {
-	AccessPolicies types.Map    `tfsdk:"access_policies"`
-	Escalations    types.Map    `tfsdk:"escalations"`
-	Id             types.String `tfsdk:"id"`
-	IamPath        types.String `tfsdk:"iam_path"`
-	UserEmail      types.String `tfsdk:"user_email"` // optional filter for user email
+	AccessPolicies     types.Map    `tfsdk:"access_policies"`
+	Escalations        types.Map    `tfsdk:"escalations"`
+	EscalationExpiries types.Map    `tfsdk:"escalation_expiries"`
+	EscalationDetails  types.Map    `tfsdk:"escalation_details"`
+	Id                 types.String `tfsdk:"id"`
+	IamPath            types.String `tfsdk:"iam_path"`
+	UserEmail          types.String `tfsdk:"user_email"` // optional filter for user email
+	Project            types.String `tfsdk:"project"`    // optional filter for project
+	ByProject          types.Map    `tfsdk:"by_project"`
+
+	IncludeServiceAccounts types.Bool `tfsdk:"include_service_accounts"`
+	Identities             types.Map  `tfsdk:"identities"`
+	Explain                types.Bool `tfsdk:"explain"`
+	Explanations           types.Map  `tfsdk:"explanations"`
+	IncludeInactive        types.Bool `tfsdk:"include_inactive"`
+	Inactive               types.Map  `tfsdk:"inactive"`
+}
+
+// EscalationDetailsModel is the escalation to the roles of a project along with
+// its requirements.
+type EscalationDetailsModel struct {
+	Roles                 []string     `tfsdk:"roles"`
+	MaxDuration           types.String `tfsdk:"max_duration"` // Go duration, null if unlimited
+	RequiresJustification bool         `tfsdk:"requires_justification"`
+	Approvers             []string     `tfsdk:"approvers"` // group and user emails
+}
+
+var EscalationDetailsAttrTypes = map[string]attr.Type{
+	"roles":                  types.ListType{ElemType: types.StringType},
+	"max_duration":           types.StringType,
+	"requires_justification": types.BoolType,
+	"approvers":              types.ListType{ElemType: types.StringType},
 }
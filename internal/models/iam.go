@@ -0,0 +1,59 @@
+package models
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type IdentityModel struct {
+	DisplayName    types.String `tfsdk:"display_name"`    // full name of the user
+	Team           types.String `tfsdk:"team"`            // team of the user
+	Manager        types.String `tfsdk:"manager"`         // email of the user's manager
+	EmploymentType types.String `tfsdk:"employment_type"` // e.g. employee or contractor
+	StartDate      types.String `tfsdk:"start_date"`      // YYYY-MM-DD
+}
+
+var IdentityAttrTypes = map[string]attr.Type{
+	"display_name":    types.StringType,
+	"team":            types.StringType,
+	"manager":         types.StringType,
+	"employment_type": types.StringType,
+	"start_date":      types.StringType,
+}
+
+// SourceModel is the file an effective grant or setting comes from.
+type SourceModel struct {
+	Source types.String `tfsdk:"source"` // user or group:<name>
+	File   types.String `tfsdk:"file"`   // path of the IAM file
+}
+
+var SourceAttrTypes = map[string]attr.Type{
+	"source": types.StringType,
+	"file":   types.StringType,
+}
+
+var GsudoExplanationAttrTypes = map[string]attr.Type{
+	"escalations":     types.MapType{ElemType: types.MapType{ElemType: types.ListType{ElemType: types.ObjectType{AttrTypes: SourceAttrTypes}}}},
+	"access_policies": types.ObjectType{AttrTypes: SourceAttrTypes},
+}
+
+var TwingateExplanationAttrTypes = map[string]attr.Type{
+	"enabled": types.ObjectType{AttrTypes: SourceAttrTypes},
+	"admin":   types.ObjectType{AttrTypes: SourceAttrTypes},
+}
+
+// InactiveModel is a suspended or offboarded user, group or service account
+// with the memberships to revoke.
+type InactiveModel struct {
+	Status         string       `tfsdk:"status"`          // suspended or offboarded
+	OffboardedAt   types.String `tfsdk:"offboarded_at"`   // YYYY-MM-DD, null if unset
+	Groups         []string     `tfsdk:"groups"`          // groups listed in the file
+	TwingateGroups []string     `tfsdk:"twingate_groups"` // Twingate groups, including inherited ones
+}
+
+var InactiveAttrTypes = map[string]attr.Type{
+	"status":          types.StringType,
+	"offboarded_at":   types.StringType,
+	"groups":          types.ListType{ElemType: types.StringType},
+	"twingate_groups": types.ListType{ElemType: types.StringType},
+}
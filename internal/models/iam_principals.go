@@ -0,0 +1,63 @@
+package models
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type IamPrincipalsDataSourceModel struct {
+	IamPath    types.String `tfsdk:"iam_path"`
+	Type       types.String `tfsdk:"type"`        // optional filter for the type of principal
+	Group      types.String `tfsdk:"group"`       // optional filter for the group of principals
+	Team       types.String `tfsdk:"team"`        // optional filter for the team of principals
+	EmailRegex types.String `tfsdk:"email_regex"` // optional filter for the email of principals
+	Principals types.List   `tfsdk:"principals"`  // list of PrincipalModel
+	Id         types.String `tfsdk:"id"`
+
+	IncludeInactive    types.Bool `tfsdk:"include_inactive"`
+	InactivePrincipals types.List `tfsdk:"inactive_principals"` // list of PrincipalModel
+}
+
+// PrincipalModel is a user, group or service account of the IAM directory
+// with its effective access.
+type PrincipalModel struct {
+	Email   string       `tfsdk:"email"`
+	Type    string       `tfsdk:"type"`    // user, group or service_account
+	Project types.String `tfsdk:"project"` // project of a service account
+	Groups  []string     `tfsdk:"groups"`  // groups listed in the file
+
+	Status       string       `tfsdk:"status"`        // active, suspended or offboarded
+	OffboardedAt types.String `tfsdk:"offboarded_at"` // YYYY-MM-DD, null if unset
+
+	DisplayName    types.String `tfsdk:"display_name"`
+	Team           types.String `tfsdk:"team"`
+	Manager        types.String `tfsdk:"manager"`
+	EmploymentType types.String `tfsdk:"employment_type"`
+	StartDate      types.String `tfsdk:"start_date"`
+
+	GcpRoles            map[string][]string `tfsdk:"gcp_roles"`             // standing roles by project
+	GsudoEscalations    map[string][]string `tfsdk:"gsudo_escalations"`     // effective escalations by project
+	GsudoAccessPolicies bool                `tfsdk:"gsudo_access_policies"` // effective access policies setting
+	Twingate            TwingateAccessModel `tfsdk:"twingate"`              // effective Twingate access
+}
+
+var PrincipalAttrTypes = map[string]attr.Type{
+	"email":   types.StringType,
+	"type":    types.StringType,
+	"project": types.StringType,
+	"groups":  types.ListType{ElemType: types.StringType},
+
+	"status":        types.StringType,
+	"offboarded_at": types.StringType,
+
+	"display_name":    types.StringType,
+	"team":            types.StringType,
+	"manager":         types.StringType,
+	"employment_type": types.StringType,
+	"start_date":      types.StringType,
+
+	"gcp_roles":             types.MapType{ElemType: types.ListType{ElemType: types.StringType}},
+	"gsudo_escalations":     types.MapType{ElemType: types.ListType{ElemType: types.StringType}},
+	"gsudo_access_policies": types.BoolType,
+	"twingate":              types.ObjectType{AttrTypes: TwingateAccessAttrTypes},
+}
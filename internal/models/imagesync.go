@@ -5,9 +5,26 @@ import (
 )
 
 type ImageSyncResourceModel struct {
-	Source       types.String `tfsdk:"source"`
-	Destination  types.String `tfsdk:"destination"`
-	SourceDigest types.String `tfsdk:"source_digest"`
-	KmsKeyId     types.String `tfsdk:"kms_key_id"`
-	Id           types.String `tfsdk:"id"`
+	Source          types.String `tfsdk:"source"`
+	Destination     types.String `tfsdk:"destination"`
+	SourceDigest    types.String `tfsdk:"source_digest"`
+	KmsKeyId        types.String `tfsdk:"kms_key_id"`
+	Artifact        types.Bool   `tfsdk:"artifact"`
+	AdditionalTags  types.Set    `tfsdk:"additional_tags"`
+	RetainPrevious  types.Int64  `tfsdk:"retain_previous"`
+	PreviousDigests types.List   `tfsdk:"previous_digests"`
+	GenerateSbom    types.Bool   `tfsdk:"generate_sbom"`
+	SbomFormat      types.String `tfsdk:"sbom_format"`
+	SbomDigest      types.String `tfsdk:"sbom_digest"`
+	Id              types.String `tfsdk:"id"`
+
+	VulnerabilityGate     *VulnerabilityGateModel `tfsdk:"vulnerability_gate"`
+	VulnerabilityFindings types.Map               `tfsdk:"vulnerability_findings"`
+}
+
+type VulnerabilityGateModel struct {
+	DbPath            types.String `tfsdk:"db_path"`
+	SeverityThreshold types.String `tfsdk:"severity_threshold"`
+	Allow             types.Set    `tfsdk:"allow"`
+	IgnoreUnfixed     types.Bool   `tfsdk:"ignore_unfixed"`
 }
@@ -10,14 +10,31 @@ type TwingateAccessDataSourceModel struct {
 	TwingateAccess types.Map    `tfsdk:"twingate_access"` // Map of users to Twingate access
 	IamPath        types.String `tfsdk:"iam_path"`        // Path to the root of the IAM directory containing user and group definitions
 	UserEmail      types.String `tfsdk:"user_email"`      // Email of the user to retrieve Twingate access for
+
+	IncludeServiceAccounts types.Bool `tfsdk:"include_service_accounts"` // Include service accounts in the results
+	Identities             types.Map  `tfsdk:"identities"`               // Map of users to their identity fields
+	Explain                types.Bool `tfsdk:"explain"`                  // Return the source of the access of each user
+	Explanations           types.Map  `tfsdk:"explanations"`             // Map of users to the source of their access
+	TwingateGroups         types.Map  `tfsdk:"twingate_groups"`          // Map of Twingate groups to their members
+	TwingateResources      types.Map  `tfsdk:"twingate_resources"`       // Map of Twingate Resources to the groups allowed to access them
+	IncludeInactive        types.Bool `tfsdk:"include_inactive"`         // Return the inactive entities
+	Inactive               types.Map  `tfsdk:"inactive"`                 // Map of inactive entities to the memberships to revoke
 }
 
 type TwingateAccessModel struct {
-	Enabled bool `tfsdk:"enabled"` // whether the user has Twingate access
-	Admin   bool `tfsdk:"admin"`   // whether the user has Twingate admin access
+	Enabled bool         `tfsdk:"enabled"` // whether the user has Twingate access
+	Admin   bool         `tfsdk:"admin"`   // whether the user has Twingate admin access
+	Expires types.String `tfsdk:"expires"` // RFC 3339 expiry of the access, null if it doesn't expire
+
+	Groups    []string `tfsdk:"groups"`    // Twingate groups of the user, including inherited ones
+	Resources []string `tfsdk:"resources"` // Twingate Resources of the user, including inherited ones
 }
 
 var TwingateAccessAttrTypes = map[string]attr.Type{
 	"enabled": types.BoolType,
 	"admin":   types.BoolType,
+	"expires": types.StringType,
+
+	"groups":    types.ListType{ElemType: types.StringType},
+	"resources": types.ListType{ElemType: types.StringType},
 }
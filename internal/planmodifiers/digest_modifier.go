@@ -12,7 +12,9 @@ import (
 
 // ImageDigestModifier returns an attribute plan modifier that checks the digest
 // of the source image and adds it to the plan. If the source digest doesn't
-// match with what we have in the state, it will trigger a replacement.
+// match with what we have in the state, it will trigger a replacement, unless
+// previous digests are retained: the new digest is then mirrored in place so
+// that the replaced one can be kept.
 func ImageDigestModifier() planmodifier.String {
 	return ImageDigest{}
 }
@@ -39,8 +41,16 @@ func (r ImageDigest) PlanModifyString(ctx context.Context, req planmodifier.Stri
 
 	source := data.Source.ValueString()
 
-	// let's get the source image digest
-	_, exists, srcDigest, err := image.GetRemoteImage(data.Source.ValueString(), authn.Anonymous)
+	// let's get the source image digest, artifacts are resolved by descriptor so
+	// that indexes are not resolved to a platform specific image
+	var exists bool
+	var srcDigest string
+	var err error
+	if data.Artifact.ValueBool() {
+		_, exists, srcDigest, err = image.GetRemoteDescriptor(source, authn.Anonymous)
+	} else {
+		_, exists, srcDigest, err = image.GetImage(source, authn.Anonymous)
+	}
 	switch {
 	case err != nil:
 		resp.Diagnostics.AddError("failed to get remote image", err.Error())
@@ -58,5 +68,5 @@ func (r ImageDigest) PlanModifyString(ctx context.Context, req planmodifier.Stri
 		return
 	}
 
-	resp.RequiresReplace = true
+	resp.RequiresReplace = data.RetainPrevious.ValueInt64() <= 0
 }
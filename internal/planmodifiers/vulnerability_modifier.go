@@ -0,0 +1,207 @@
+package planmodifiers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/ravelin-community/terraform-provider-ravelin/internal/image"
+	"github.com/ravelin-community/terraform-provider-ravelin/internal/models"
+	"github.com/ravelin-community/terraform-provider-ravelin/internal/vulndb"
+)
+
+// DefaultSeverityThreshold is the severity threshold of the vulnerability gate
+// when none is configured.
+const DefaultSeverityThreshold = "critical"
+
+// vulnerabilityDBKey is the private state key holding the digest of the
+// vulnerability database the findings were computed with.
+const vulnerabilityDBKey = "vulnerability_db"
+
+// PrivateState is the private state of a resource, as found in plan modifier
+// and resource responses.
+type PrivateState interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// DefaultIgnoreUnfixed is whether the vulnerability gate ignores the
+// vulnerabilities without a fix when not configured.
+const DefaultIgnoreUnfixed = true
+
+// VulnerabilityGateModifier returns an attribute plan modifier that scans the
+// source image against the vulnerability database of the gate and adds the
+// findings counts to the plan. The plan fails if the image doesn't pass the
+// gate, so that images are refused before anything is mirrored.
+func VulnerabilityGateModifier() planmodifier.Map {
+	return VulnerabilityGate{}
+}
+
+type VulnerabilityGate struct{}
+
+func (r VulnerabilityGate) Description(ctx context.Context) string {
+	return "Scans the source image for vulnerabilities and fails the plan if the image doesn't pass the vulnerability gate."
+}
+
+func (r VulnerabilityGate) MarkdownDescription(ctx context.Context) string {
+	return r.Description(ctx)
+}
+
+func (r VulnerabilityGate) PlanModifyMap(ctx context.Context, req planmodifier.MapRequest, resp *planmodifier.MapResponse) {
+	// nothing to scan if we're destroying the resource
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var data models.ImageSyncResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if data.VulnerabilityGate == nil {
+		resp.PlanValue = types.MapNull(types.Int64Type)
+		return
+	}
+	if data.Source.IsUnknown() || data.VulnerabilityGate.DbPath.IsUnknown() {
+		return
+	}
+	if data.Artifact.ValueBool() {
+		resp.Diagnostics.AddError("vulnerability gate is not supported for artifacts", "`vulnerability_gate` can only be used with container images")
+		return
+	}
+
+	source := data.Source.ValueString()
+	img, exists, srcDigest, err := image.GetImage(source, authn.Anonymous)
+	switch {
+	case err != nil:
+		resp.Diagnostics.AddError("failed to get remote image", err.Error())
+		return
+	case !exists:
+		resp.Diagnostics.AddError("source image does not exist", source)
+		return
+	}
+
+	// the scan is only repeated when the image, the gate or the content of the
+	// database changes, as it requires pulling every layer of the image
+	if !req.State.Raw.IsNull() {
+		var state models.ImageSyncResourceModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		dbDigest, err := vulndb.Digest(data.VulnerabilityGate.DbPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("failed to read vulnerability database", err.Error())
+			return
+		}
+		scannedDigest, diags := scannedDatabase(ctx, req.Private)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if state.SourceDigest.ValueString() == srcDigest && gateEqual(state.VulnerabilityGate, data.VulnerabilityGate) &&
+			scannedDigest == dbDigest && !req.StateValue.IsNull() {
+			resp.PlanValue = req.StateValue
+			return
+		}
+	}
+
+	findings, diags := CheckVulnerabilities(ctx, img, data.VulnerabilityGate, resp.Private)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.PlanValue = findings
+}
+
+// CheckVulnerabilities scans the image against the vulnerability database of
+// the gate. It returns the findings counts per severity, and an error listing
+// the findings failing the gate, if any. The digest of the database is recorded
+// in the private state so that the scan is repeated when the database changes.
+func CheckVulnerabilities(ctx context.Context, img v1.Image, gate *models.VulnerabilityGateModel, private PrivateState) (types.Map, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	threshold := gate.SeverityThreshold.ValueString()
+	if threshold == "" {
+		threshold = DefaultSeverityThreshold
+	}
+	severity, err := vulndb.ParseSeverity(threshold)
+	if err != nil {
+		diags.AddError("invalid severity_threshold", err.Error())
+		return types.MapNull(types.Int64Type), diags
+	}
+
+	ignoreUnfixed := DefaultIgnoreUnfixed
+	if !gate.IgnoreUnfixed.IsNull() && !gate.IgnoreUnfixed.IsUnknown() {
+		ignoreUnfixed = gate.IgnoreUnfixed.ValueBool()
+	}
+
+	var allow []string
+	diags.Append(gate.Allow.ElementsAs(ctx, &allow, false)...)
+	if diags.HasError() {
+		return types.MapNull(types.Int64Type), diags
+	}
+
+	res, err := vulndb.ScanImage(img, gate.DbPath.ValueString(), vulndb.Gate{
+		Threshold:     severity,
+		Allow:         allow,
+		IgnoreUnfixed: ignoreUnfixed,
+	})
+	if err != nil {
+		diags.AddError("failed to scan image for vulnerabilities", err.Error())
+		return types.MapNull(types.Int64Type), diags
+	}
+	for _, f := range res.Skipped {
+		diags.AddWarning("file skipped from the vulnerability scan", f.String())
+	}
+	value, err := json.Marshal(res.Database)
+	if err != nil {
+		diags.AddError("failed to record the vulnerability database", err.Error())
+		return types.MapNull(types.Int64Type), diags
+	}
+	diags.Append(private.SetKey(ctx, vulnerabilityDBKey, value)...)
+
+	if len(res.Blocking) > 0 {
+		diags.AddError(
+			"image failed the vulnerability gate",
+			fmt.Sprintf("%d vulnerabilities at or above the %s severity threshold:\n%s", len(res.Blocking), severity, res.Summary()),
+		)
+		return types.MapNull(types.Int64Type), diags
+	}
+
+	findings, d := types.MapValueFrom(ctx, types.Int64Type, res.Counts)
+	diags.Append(d...)
+	return findings, diags
+}
+
+// scannedDatabase returns the digest of the vulnerability database the findings
+// in state were computed with, if recorded.
+func scannedDatabase(ctx context.Context, private PrivateState) (string, diag.Diagnostics) {
+	value, diags := private.GetKey(ctx, vulnerabilityDBKey)
+	if diags.HasError() || value == nil {
+		return "", diags
+	}
+
+	var digest string
+	if err := json.Unmarshal(value, &digest); err != nil {
+		diags.AddError("failed to read the vulnerability database from private state", err.Error())
+	}
+	return digest, diags
+}
+
+// gateEqual returns true if both gates have the same configuration.
+func gateEqual(a, b *models.VulnerabilityGateModel) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.DbPath.Equal(b.DbPath) &&
+		a.SeverityThreshold.Equal(b.SeverityThreshold) &&
+		a.Allow.Equal(b.Allow) &&
+		a.IgnoreUnfixed.Equal(b.IgnoreUnfixed)
+}
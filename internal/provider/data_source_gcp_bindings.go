@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/ravelin-community/terraform-provider-ravelin/internal/models"
+	iam "github.com/ravelin-community/terraform-provider-ravelin/internal/ravelinaccess"
+)
+
+var _ datasource.DataSource = &GcpBindingsDataSource{}
+
+type GcpBindingsDataSource struct {
+	provider *ravelinProvider
+}
+
+func (r *GcpBindingsDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_gcp_bindings"
+}
+
+func (r *GcpBindingsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"iam_path": schema.StringAttribute{
+				MarkdownDescription: "Path to the root of the IAM directory containing user and group definitions",
+				Required:            true,
+			},
+			"project": schema.StringAttribute{
+				MarkdownDescription: "Project to generate the bindings of. If not specified, the bindings of every project are returned.",
+				Optional:            true,
+			},
+			"bindings": schema.MapAttribute{
+				MarkdownDescription: "Map of projects to the members of each role. The key is the project name and the value is a map of " +
+					"roles to the sorted members granted the role in the `gcp.roles` of the IAM files, prefixed with `user:`, `group:` " +
+					"or `serviceAccount:`.",
+				Computed:    true,
+				ElementType: types.MapType{ElemType: types.ListType{ElemType: types.StringType}},
+			},
+			"id": schema.StringAttribute{
+				MarkdownDescription: "Hash of the returned bindings, only changing when they do.",
+				Computed:            true,
+			},
+		},
+		MarkdownDescription: "Generate the authoritative IAM bindings of the standing project roles of the IAM directory.\n\n" +
+			"Use this data source to bind the `gcp.roles` of the users, groups and service accounts with " +
+			"`google_project_iam_binding` resources, or to compose them into a project policy.",
+	}
+}
+
+func (d *GcpBindingsDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*ravelinProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ravelinProvider, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.provider = provider
+}
+
+func (d *GcpBindingsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data models.GcpBindingsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	dir, err := loadIamDirectory(d.provider, data.IamPath.ValueString())
+	if err != nil {
+		addIamErrors(&resp.Diagnostics, "failed to load IAM directory", err)
+		return
+	}
+	addDirectoryWarnings(&resp.Diagnostics, dir)
+
+	// bindings are authoritative, so they include every member of the directory
+	allAccess := append(dir.Entities(true), dir.Groups()...)
+	bindings := gcpBindings(allAccess, data.Project.ValueString())
+
+	var diags diag.Diagnostics
+	data.Bindings, diags = types.MapValueFrom(ctx, types.MapType{ElemType: types.ListType{ElemType: types.StringType}}, bindings)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.Id = types.StringValue(contentID(data.Bindings))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// gcpBindings returns the members of the project roles by project and role,
+// only for the project if set. Members are sorted.
+func gcpBindings(allAccess []iam.RavelinAccess, project string) map[string]map[string][]string {
+	bindings := make(map[string]map[string][]string)
+	for _, access := range allAccess {
+		for p, roles := range access.GCP.Roles {
+			if project != "" && p != project {
+				continue
+			}
+			if bindings[p] == nil {
+				bindings[p] = make(map[string][]string)
+			}
+			for _, role := range roles {
+				bindings[p][role] = append(bindings[p][role], iamMember(access))
+			}
+		}
+	}
+	for _, roles := range bindings {
+		for role, members := range roles {
+			slices.Sort(members)
+			roles[role] = slices.Compact(members)
+		}
+	}
+	return bindings
+}
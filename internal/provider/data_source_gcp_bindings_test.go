@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	iam "github.com/ravelin-community/terraform-provider-ravelin/internal/ravelinaccess"
+)
+
+func TestGcpBindings(t *testing.T) {
+	allAccess := []iam.RavelinAccess{
+		{
+			Email: "john.doe@ravelin.com",
+			Type:  iam.USER,
+			GCP: iam.GCPAccess{
+				Roles: map[string][]string{"my-project": {"roles/viewer", "projects/my-project/roles/auditor"}, "other-project": {"roles/viewer"}},
+			},
+		},
+		{
+			Email: "ci-bot@my-project.iam.gserviceaccount.com",
+			Type:  iam.SERVICE,
+			GCP: iam.GCPAccess{
+				Roles: map[string][]string{"my-project": {"roles/viewer"}},
+			},
+		},
+		{
+			Email: "gcp-platform@ravelin.com",
+			Type:  iam.GROUP,
+			GCP: iam.GCPAccess{
+				Roles: map[string][]string{"my-project": {"roles/viewer", "roles/viewer"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		project  string
+		expected map[string]map[string][]string
+	}{
+		{
+			name: "all_projects",
+			expected: map[string]map[string][]string{
+				"my-project": {
+					"projects/my-project/roles/auditor": {"user:john.doe@ravelin.com"},
+					"roles/viewer": {
+						"group:gcp-platform@ravelin.com",
+						"serviceAccount:ci-bot@my-project.iam.gserviceaccount.com",
+						"user:john.doe@ravelin.com",
+					},
+				},
+				"other-project": {"roles/viewer": {"user:john.doe@ravelin.com"}},
+			},
+		},
+		{
+			name:    "project_filter",
+			project: "other-project",
+			expected: map[string]map[string][]string{
+				"other-project": {"roles/viewer": {"user:john.doe@ravelin.com"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.expected, gcpBindings(allAccess, tt.project)); diff != "" {
+				t.Errorf("unexpected bindings (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/ravelin-community/terraform-provider-ravelin/internal/models"
+)
+
+var _ datasource.DataSource = &GroupMembershipsDataSource{}
+
+type GroupMembershipsDataSource struct {
+	provider *ravelinProvider
+}
+
+func (r *GroupMembershipsDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_group_memberships"
+}
+
+func (r *GroupMembershipsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"iam_path": schema.StringAttribute{
+				MarkdownDescription: "Path to the root of the IAM directory containing user and group definitions",
+				Required:            true,
+			},
+			"include_service_accounts": schema.BoolAttribute{
+				MarkdownDescription: "Include the service accounts defined in the `service-accounts` directory of the IAM directory as members. Defaults to `false`.",
+				Optional:            true,
+			},
+			"transitive": schema.BoolAttribute{
+				MarkdownDescription: "Make users and service accounts members of the groups they belong to through nested groups as well, " +
+					"instead of making groups members of the groups they list. Defaults to `false`.",
+				Optional: true,
+			},
+			"include_inactive": includeInactiveAttribute(),
+			"inactive":         inactiveAttribute(),
+			"memberships": schema.MapAttribute{
+				MarkdownDescription: "Map of groups to their members. The key is the group email and the value is the sorted emails of the " +
+					"users, service accounts and groups listing the group in their `gcp.groups`.",
+				Computed:    true,
+				ElementType: types.ListType{ElemType: types.StringType},
+			},
+			"id": schema.StringAttribute{
+				MarkdownDescription: "Hash of the returned memberships, only changing when they do.",
+				Computed:            true,
+			},
+		},
+		MarkdownDescription: "Get the members of the groups of the IAM directory.\n\n" +
+			"Use this data source to manage the memberships of Google Workspace or Cloud Identity groups, " +
+			"e.g. with `google_cloud_identity_group_membership` resources, from the `gcp.groups` of the IAM files.",
+	}
+}
+
+func (d *GroupMembershipsDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*ravelinProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ravelinProvider, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.provider = provider
+}
+
+func (d *GroupMembershipsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data models.GroupMembershipsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	dir, err := loadIamDirectory(d.provider, data.IamPath.ValueString())
+	if err != nil {
+		addIamErrors(&resp.Diagnostics, "failed to load IAM directory", err)
+		return
+	}
+	addDirectoryWarnings(&resp.Diagnostics, dir)
+	for _, warning := range dir.MissingGroups() {
+		resp.Diagnostics.AddWarning("group without group file", warning)
+	}
+
+	memberships, err := dir.Memberships(data.IncludeServiceAccounts.ValueBool(), data.Transitive.ValueBool())
+	if err != nil {
+		resp.Diagnostics.AddError("failed to resolve group memberships", err.Error())
+		return
+	}
+
+	var diags diag.Diagnostics
+	data.Memberships, diags = types.MapValueFrom(ctx, types.ListType{ElemType: types.StringType}, memberships)
+	resp.Diagnostics.Append(diags...)
+	data.Inactive, diags = inactiveToMap(ctx, dir, data.IncludeInactive.ValueBool(), data.IncludeServiceAccounts.ValueBool())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.Id = types.StringValue(contentID(data.Memberships, data.Inactive))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
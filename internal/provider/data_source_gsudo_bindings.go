@@ -0,0 +1,358 @@
+package provider
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/ravelin-community/terraform-provider-ravelin/internal/models"
+	iam "github.com/ravelin-community/terraform-provider-ravelin/internal/ravelinaccess"
+	"google.golang.org/api/cloudresourcemanager/v1"
+)
+
+// Default templates of the conditions of the gsudo bindings. Roles without an
+// expiry render an empty expression, so their bindings have no condition.
+const (
+	defaultGsudoConditionTitle       = `gsudo {{ .Role }}`
+	defaultGsudoConditionDescription = `Escalation to {{ .Role }} on {{ .Project }} managed by gsudo`
+	defaultGsudoConditionExpression  = `{{ with .Expires }}{{ expiresAt . }}{{ end }}`
+)
+
+var _ datasource.DataSource = &GsudoBindingsDataSource{}
+
+type GsudoBindingsDataSource struct {
+	provider *ravelinProvider
+}
+
+func (r *GsudoBindingsDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_gsudo_bindings"
+}
+
+func (r *GsudoBindingsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"iam_path": schema.StringAttribute{
+				MarkdownDescription: "Path to the root of the IAM directory containing user and group definitions",
+				Required:            true,
+			},
+			"project": schema.StringAttribute{
+				MarkdownDescription: "Name of the GCP project to generate the bindings of. If not specified, the provider-level project will be used.",
+				Optional:            true,
+			},
+			"include_service_accounts": schema.BoolAttribute{
+				MarkdownDescription: "Include the escalations of the service accounts defined in the `service-accounts` directory of the IAM directory. Defaults to `false`.",
+				Optional:            true,
+			},
+			"bindings": schema.ListNestedAttribute{
+				MarkdownDescription: "Bindings of the escalation roles of the project, one per role and condition, sorted by role and condition title. " +
+					"The condition is null when the rendered expression is empty.",
+				Computed: true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"role": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Role of the binding",
+						},
+						"members": schema.ListAttribute{
+							Computed:            true,
+							MarkdownDescription: "Members of the binding, sorted",
+							ElementType:         types.StringType,
+						},
+						"condition": schema.SingleNestedAttribute{
+							Computed: true,
+							Attributes: map[string]schema.Attribute{
+								"title": schema.StringAttribute{
+									Computed:            true,
+									MarkdownDescription: "Title of the condition",
+								},
+								"description": schema.StringAttribute{
+									Computed:            true,
+									MarkdownDescription: "Description of the condition",
+								},
+								"expression": schema.StringAttribute{
+									Computed:            true,
+									MarkdownDescription: "Expression of the condition",
+								},
+							},
+						},
+					},
+				},
+			},
+			"id": schema.StringAttribute{
+				MarkdownDescription: "Hash of the returned bindings, only changing when they do.",
+				Computed:            true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"condition": schema.SingleNestedBlock{
+				MarkdownDescription: "Templates of the conditions of the bindings, using the Go `text/template` syntax. " +
+					"Templates are rendered for every member of a role with `.Project`, `.Role`, `.Member` and `.Expires`, " +
+					"the RFC 3339 effective expiry of the role, empty if it doesn't expire. The `expiresAt`, `timeWindow`, " +
+					"`resourcePrefix` and `matchTag` functions render CEL expressions.",
+				Attributes: map[string]schema.Attribute{
+					"title": schema.StringAttribute{
+						MarkdownDescription: "Template of the condition title. Defaults to `" + defaultGsudoConditionTitle + "`.",
+						Optional:            true,
+					},
+					"description": schema.StringAttribute{
+						MarkdownDescription: "Template of the condition description. Defaults to `" + defaultGsudoConditionDescription + "`.",
+						Optional:            true,
+					},
+					"expression": schema.StringAttribute{
+						MarkdownDescription: "Template of the CEL expression of the condition. Defaults to `" + defaultGsudoConditionExpression + "`.",
+						Optional:            true,
+					},
+				},
+			},
+		},
+		MarkdownDescription: "Generate the IAM bindings of the gsudo escalations of a project.\n\n" +
+			"Use this data source to turn the escalations of the IAM directory into conditional bindings, " +
+			"which can be added to a `google_project_iam_policy` resource along with the service agent bindings.",
+	}
+}
+
+func (d *GsudoBindingsDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*ravelinProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ravelinProvider, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.provider = provider
+}
+
+func (d *GsudoBindingsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data models.GsudoBindingsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	project := data.Project.ValueString()
+	if project == "" && d.provider != nil {
+		project = d.provider.project
+	}
+	if project == "" {
+		resp.Diagnostics.AddError(
+			"Missing Project Configuration",
+			"The project attribute is required when not specified in the provider configuration.",
+		)
+		return
+	}
+
+	tmpl, err := newConditionTemplate(data.Condition)
+	if err != nil {
+		resp.Diagnostics.AddError("invalid condition template", err.Error())
+		return
+	}
+
+	dir, err := loadIamDirectory(d.provider, data.IamPath.ValueString())
+	if err != nil {
+		addIamErrors(&resp.Diagnostics, "failed to load IAM directory", err)
+		return
+	}
+	addDirectoryWarnings(&resp.Diagnostics, dir)
+
+	allAccess := dir.Entities(data.IncludeServiceAccounts.ValueBool())
+	for i := range allAccess {
+		if err := allAccess[i].InheritGsudoAccess(); err != nil {
+			resp.Diagnostics.AddError("failed to inherit gsudo access", err.Error())
+			return
+		}
+	}
+	// the escalations of the groups themselves are bound to the group, as in
+	// the by_project attribute of the gsudo_escalations data source
+	allAccess = append(allAccess, dir.Groups()...)
+
+	bindings, err := gsudoBindings(allAccess, project, tmpl)
+	if err != nil {
+		resp.Diagnostics.AddError("failed to render condition", err.Error())
+		return
+	}
+
+	conditionalBindings := make([]models.ConditionalBindingModel, len(bindings))
+	for i, binding := range bindings {
+		var diags diag.Diagnostics
+		conditionalBindings[i], diags = newGsudoBinding(ctx, binding)
+		resp.Diagnostics.Append(diags...)
+	}
+
+	var diags diag.Diagnostics
+	data.Bindings, diags = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: models.ConditionalBindingAttrTypes}, conditionalBindings)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.Id = types.StringValue(contentID(data.Bindings))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// conditionTemplate renders the conditions of the gsudo bindings.
+type conditionTemplate struct {
+	title, description, expression *template.Template
+}
+
+// conditionData is the data the condition templates are rendered with.
+type conditionData struct {
+	Project string
+	Role    string
+	Member  string
+	// Expires is the RFC 3339 effective expiry of the role, empty if it doesn't
+	// expire.
+	Expires string
+}
+
+// conditionFuncs are the functions of the condition templates, they render
+// CEL expressions.
+var conditionFuncs = template.FuncMap{
+	// expiresAt grants the role until the RFC 3339 timestamp.
+	"expiresAt": func(timestamp string) string {
+		return fmt.Sprintf("request.time < timestamp(%q)", timestamp)
+	},
+	// timeWindow grants the role between the start and end hours, in the time
+	// zone, e.g. `timeWindow 9 18 "Europe/London"`.
+	"timeWindow": func(start, end int, timeZone string) string {
+		return fmt.Sprintf("request.time.getHours(%q) >= %d && request.time.getHours(%q) < %d", timeZone, start, timeZone, end)
+	},
+	// resourcePrefix grants the role on the resources whose name starts with
+	// the prefix.
+	"resourcePrefix": func(prefix string) string {
+		return fmt.Sprintf("resource.name.startsWith(%q)", prefix)
+	},
+	// matchTag grants the role on the resources with the tag, the key being
+	// namespaced, e.g. `matchTag "123456789012/env" "staging"`.
+	"matchTag": func(key, value string) string {
+		return fmt.Sprintf("resource.matchTag(%q, %q)", key, value)
+	},
+}
+
+func newConditionTemplate(m *models.GsudoBindingsConditionTemplateModel) (conditionTemplate, error) {
+	texts := map[string]string{
+		"title":       defaultGsudoConditionTitle,
+		"description": defaultGsudoConditionDescription,
+		"expression":  defaultGsudoConditionExpression,
+	}
+	if m != nil {
+		for name, value := range map[string]types.String{"title": m.Title, "description": m.Description, "expression": m.Expression} {
+			if !value.IsNull() {
+				texts[name] = value.ValueString()
+			}
+		}
+	}
+
+	parsed := make(map[string]*template.Template, len(texts))
+	for name, text := range texts {
+		t, err := template.New(name).Funcs(conditionFuncs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return conditionTemplate{}, fmt.Errorf("error parsing %s template: %w", name, err)
+		}
+		parsed[name] = t
+	}
+	return conditionTemplate{title: parsed["title"], description: parsed["description"], expression: parsed["expression"]}, nil
+}
+
+func (t conditionTemplate) render(data conditionData) (*cloudresourcemanager.Expr, error) {
+	var expr cloudresourcemanager.Expr
+	for _, field := range []struct {
+		tmpl *template.Template
+		out  *string
+	}{{t.title, &expr.Title}, {t.description, &expr.Description}, {t.expression, &expr.Expression}} {
+		var b strings.Builder
+		if err := field.tmpl.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("error rendering %s of %s for %s: %w", field.tmpl.Name(), data.Role, data.Member, err)
+		}
+		*field.out = strings.TrimSpace(b.String())
+	}
+
+	if expr.Expression == "" {
+		return nil, nil
+	}
+	return &expr, nil
+}
+
+// gsudoBindings returns the bindings of the escalation roles of the project.
+// Members of a role sharing the same rendered condition are bound together.
+func gsudoBindings(allAccess []iam.RavelinAccess, project string, tmpl conditionTemplate) ([]*cloudresourcemanager.Binding, error) {
+	byKey := make(map[string]*cloudresourcemanager.Binding)
+	for _, access := range allAccess {
+		member := iamMember(access)
+
+		for _, role := range access.Gsudo.Escalations[project] {
+			data := conditionData{Project: project, Role: role, Member: member}
+			if expires, ok := access.Gsudo.Expiries[project][role]; ok {
+				data.Expires = expires.Format(time.RFC3339)
+			}
+
+			condition, err := tmpl.render(data)
+			if err != nil {
+				return nil, err
+			}
+
+			key := role
+			if condition != nil {
+				key = strings.Join([]string{role, condition.Title, condition.Description, condition.Expression}, "\x00")
+			}
+			binding, ok := byKey[key]
+			if !ok {
+				binding = &cloudresourcemanager.Binding{Role: role, Condition: condition}
+				byKey[key] = binding
+			}
+			binding.Members = append(binding.Members, member)
+		}
+	}
+
+	bindings := make([]*cloudresourcemanager.Binding, 0, len(byKey))
+	for _, binding := range byKey {
+		slices.Sort(binding.Members)
+		binding.Members = slices.Compact(binding.Members)
+		bindings = append(bindings, binding)
+	}
+	slices.SortFunc(bindings, func(a, b *cloudresourcemanager.Binding) int {
+		return cmp.Or(
+			cmp.Compare(a.Role, b.Role),
+			cmp.Compare(conditionKey(a.Condition), conditionKey(b.Condition)),
+		)
+	})
+	return bindings, nil
+}
+
+// conditionKey orders the bindings without condition first, then by title.
+func conditionKey(c *cloudresourcemanager.Expr) string {
+	if c == nil {
+		return ""
+	}
+	return "\x00" + c.Title + "\x00" + c.Expression
+}
+
+// newGsudoBinding converts the binding to a conditional binding, with a null
+// condition if the binding has none.
+func newGsudoBinding(ctx context.Context, in *cloudresourcemanager.Binding) (models.ConditionalBindingModel, diag.Diagnostics) {
+	if in.Condition != nil {
+		return newConditionalBinding(ctx, in)
+	}
+
+	var data models.ConditionalBindingModel
+	var diags diag.Diagnostics
+
+	data.Role = types.StringValue(in.Role)
+	data.Condition = types.ObjectNull(models.ConditionalBindingConditionAttrTypes)
+	data.Members, diags = types.ListValueFrom(ctx, types.StringType, in.Members)
+	return data, diags
+}
@@ -0,0 +1,195 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/ravelin-community/terraform-provider-ravelin/internal/models"
+	iam "github.com/ravelin-community/terraform-provider-ravelin/internal/ravelinaccess"
+	"google.golang.org/api/cloudresourcemanager/v1"
+)
+
+func TestGsudoBindings(t *testing.T) {
+	expires := time.Date(2026, 11, 30, 0, 0, 0, 0, time.UTC)
+	later := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+	allAccess := []iam.RavelinAccess{
+		{
+			Email: "john.doe@ravelin.com",
+			Type:  iam.USER,
+			Gsudo: iam.GsudoAccess{
+				Escalations: map[string][]string{"my-project": {"roles/owner", "roles/viewer"}, "other-project": {"roles/owner"}},
+				Expiries:    map[string]map[string]time.Time{"my-project": {"roles/owner": expires, "roles/viewer": expires}},
+			},
+		},
+		{
+			Email: "jane.doe@ravelin.com",
+			Type:  iam.USER,
+			Gsudo: iam.GsudoAccess{
+				Escalations: map[string][]string{"my-project": {"roles/viewer", "roles/owner"}},
+				Expiries:    map[string]map[string]time.Time{"my-project": {"roles/owner": later, "roles/viewer": expires}},
+			},
+		},
+		{
+			Email: "ci-bot@my-project.iam.gserviceaccount.com",
+			Type:  iam.SERVICE,
+			Gsudo: iam.GsudoAccess{
+				Escalations: map[string][]string{"my-project": {"roles/viewer"}},
+				Expiries:    map[string]map[string]time.Time{"my-project": {"roles/viewer": expires}},
+			},
+		},
+		{
+			Email: "sre@ravelin.com",
+			Type:  iam.GROUP,
+			Gsudo: iam.GsudoAccess{
+				Escalations: map[string][]string{"my-project": {"roles/viewer"}},
+				Expiries:    map[string]map[string]time.Time{"my-project": {"roles/viewer": expires}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		template *models.GsudoBindingsConditionTemplateModel
+		expected []*cloudresourcemanager.Binding
+	}{
+		{
+			name: "default_template",
+			expected: []*cloudresourcemanager.Binding{
+				{
+					Role:    "roles/owner",
+					Members: []string{"user:john.doe@ravelin.com"},
+					Condition: &cloudresourcemanager.Expr{
+						Title:       "gsudo roles/owner",
+						Description: "Escalation to roles/owner on my-project managed by gsudo",
+						Expression:  `request.time < timestamp("2026-11-30T00:00:00Z")`,
+					},
+				},
+				{
+					Role:    "roles/owner",
+					Members: []string{"user:jane.doe@ravelin.com"},
+					Condition: &cloudresourcemanager.Expr{
+						Title:       "gsudo roles/owner",
+						Description: "Escalation to roles/owner on my-project managed by gsudo",
+						Expression:  `request.time < timestamp("2026-12-31T00:00:00Z")`,
+					},
+				},
+				{
+					Role:    "roles/viewer",
+					Members: []string{"group:sre@ravelin.com", "serviceAccount:ci-bot@my-project.iam.gserviceaccount.com", "user:jane.doe@ravelin.com", "user:john.doe@ravelin.com"},
+					Condition: &cloudresourcemanager.Expr{
+						Title:       "gsudo roles/viewer",
+						Description: "Escalation to roles/viewer on my-project managed by gsudo",
+						Expression:  `request.time < timestamp("2026-11-30T00:00:00Z")`,
+					},
+				},
+			},
+		},
+		{
+			name: "custom_template",
+			template: &models.GsudoBindingsConditionTemplateModel{
+				Title:       types.StringValue("working-hours"),
+				Description: types.StringNull(),
+				Expression:  types.StringValue(`{{ timeWindow 9 18 "Europe/London" }} && {{ resourcePrefix "projects/_/buckets/data-" }}`),
+			},
+			expected: []*cloudresourcemanager.Binding{
+				{
+					Role:    "roles/owner",
+					Members: []string{"user:jane.doe@ravelin.com", "user:john.doe@ravelin.com"},
+					Condition: &cloudresourcemanager.Expr{
+						Title:       "working-hours",
+						Description: "Escalation to roles/owner on my-project managed by gsudo",
+						Expression:  `request.time.getHours("Europe/London") >= 9 && request.time.getHours("Europe/London") < 18 && resource.name.startsWith("projects/_/buckets/data-")`,
+					},
+				},
+				{
+					Role:    "roles/viewer",
+					Members: []string{"group:sre@ravelin.com", "serviceAccount:ci-bot@my-project.iam.gserviceaccount.com", "user:jane.doe@ravelin.com", "user:john.doe@ravelin.com"},
+					Condition: &cloudresourcemanager.Expr{
+						Title:       "working-hours",
+						Description: "Escalation to roles/viewer on my-project managed by gsudo",
+						Expression:  `request.time.getHours("Europe/London") >= 9 && request.time.getHours("Europe/London") < 18 && resource.name.startsWith("projects/_/buckets/data-")`,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := newConditionTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("newConditionTemplate() unexpected error: %v", err)
+			}
+
+			got, err := gsudoBindings(allAccess, "my-project", tmpl)
+			if err != nil {
+				t.Fatalf("gsudoBindings() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Errorf("gsudoBindings() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGsudoBindings_NoExpiry(t *testing.T) {
+	expires := time.Date(2026, 11, 30, 0, 0, 0, 0, time.UTC)
+	allAccess := []iam.RavelinAccess{
+		{
+			Email: "jane.doe@ravelin.com",
+			Type:  iam.USER,
+			Gsudo: iam.GsudoAccess{
+				Escalations: map[string][]string{"my-project": {"roles/owner"}},
+			},
+		},
+		{
+			Email: "john.doe@ravelin.com",
+			Type:  iam.USER,
+			Gsudo: iam.GsudoAccess{
+				Escalations: map[string][]string{"my-project": {"roles/owner"}},
+				Expiries:    map[string]map[string]time.Time{"my-project": {"roles/owner": expires}},
+			},
+		},
+	}
+
+	tmpl, err := newConditionTemplate(nil)
+	if err != nil {
+		t.Fatalf("newConditionTemplate() unexpected error: %v", err)
+	}
+
+	// the expression of a role without expiry renders empty with the default
+	// template, so it is bound without condition, before the conditional
+	// bindings of the role
+	got, err := gsudoBindings(allAccess, "my-project", tmpl)
+	if err != nil {
+		t.Fatalf("gsudoBindings() unexpected error: %v", err)
+	}
+	expected := []*cloudresourcemanager.Binding{
+		{Role: "roles/owner", Members: []string{"user:jane.doe@ravelin.com"}},
+		{
+			Role:    "roles/owner",
+			Members: []string{"user:john.doe@ravelin.com"},
+			Condition: &cloudresourcemanager.Expr{
+				Title:       "gsudo roles/owner",
+				Description: "Escalation to roles/owner on my-project managed by gsudo",
+				Expression:  `request.time < timestamp("2026-11-30T00:00:00Z")`,
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("gsudoBindings() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewConditionTemplate_Invalid(t *testing.T) {
+	_, err := newConditionTemplate(&models.GsudoBindingsConditionTemplateModel{
+		Title:       types.StringNull(),
+		Description: types.StringNull(),
+		Expression:  types.StringValue("{{ .Expires"),
+	})
+	if err == nil {
+		t.Errorf("newConditionTemplate() expected error but got none")
+	}
+}
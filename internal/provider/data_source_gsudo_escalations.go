@@ -3,11 +3,15 @@ package provider
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
@@ -15,7 +19,9 @@ import (
 	iam "github.com/ravelin-community/terraform-provider-ravelin/internal/ravelinaccess"
 )
 
-type GsudoEscalationsDataSource struct{}
+type GsudoEscalationsDataSource struct {
+	provider *ravelinProvider
+}
 
 func (r *GsudoEscalationsDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_gsudo_escalations"
@@ -37,11 +43,61 @@ func (r *GsudoEscalationsDataSource) Schema(ctx context.Context, req datasource.
 					},
 				},
 			},
+			"escalation_expiries": schema.MapAttribute{
+				MarkdownDescription: "Map of users to the effective expiry of their escalation roles with an expiry. The key is the user email " +
+					"and the value is a map of project names to a map of roles to their RFC 3339 expiry. Roles which don't expire are omitted, " +
+					"inherited roles expire with the membership to the group granting them.",
+				Computed: true,
+				ElementType: types.MapType{
+					ElemType: types.MapType{
+						ElemType: types.StringType,
+					},
+				},
+			},
+			"escalation_details": schema.MapAttribute{
+				MarkdownDescription: "Map of users to their escalations along with their requirements. The key is the user email and the value " +
+					"is a map of project names to an object with the escalation `roles`, the `max_duration` of the escalations as a Go duration, " +
+					"null if unlimited, whether the escalations `requires_justification` and the sorted emails of the `approvers`, groups being " +
+					"resolved to their email. Requirements inherited from groups are merged, the most restrictive value winning.",
+				Computed:    true,
+				ElementType: types.MapType{ElemType: types.ObjectType{AttrTypes: models.EscalationDetailsAttrTypes}},
+			},
+			"by_project": schema.MapAttribute{
+				MarkdownDescription: "Map of projects to the members who can escalate to each role. The key is the project name and the value " +
+					"is a map of roles to the sorted emails of the users, service accounts and groups granted the role, users and service " +
+					"accounts including the roles inherited from their groups. The `user_email` filter doesn't apply.",
+				Computed: true,
+				ElementType: types.MapType{
+					ElemType: types.ListType{
+						ElemType: types.StringType,
+					},
+				},
+			},
 			"access_policies": schema.MapAttribute{
 				MarkdownDescription: "Indicates if the user has access to switch access context policies from enforce to dry-run mode.",
 				Computed:            true,
 				ElementType:         types.BoolType,
 			},
+			"include_service_accounts": schema.BoolAttribute{
+				MarkdownDescription: "Include the service accounts defined in the `service-accounts` directory of the IAM directory, keyed by their email. Defaults to `false`.",
+				Optional:            true,
+			},
+			"identities":       identitiesAttribute(),
+			"explain":          explainAttribute(),
+			"include_inactive": includeInactiveAttribute(),
+			"inactive":         inactiveAttribute(),
+			"explanations": schema.MapAttribute{
+				MarkdownDescription: "Map of users to the source of their effective access, only set when `explain` is `true`. The key is the user " +
+					"email and the value is an object with the `escalations`, a map of projects to roles to the list of sources granting them, " +
+					"and the source deciding `access_policies`, null if no file sets it. Sources are objects with the `source`, `user` or " +
+					"`group:<name>`, and the `file` of the grant, the user file first and then the groups from the nearest to the farthest.",
+				Computed:    true,
+				ElementType: types.ObjectType{AttrTypes: models.GsudoExplanationAttrTypes},
+			},
+			"project": schema.StringAttribute{
+				MarkdownDescription: "Project to filter escalations for. If specified, `escalations`, `escalation_details`, `escalation_expiries` and `by_project` only include the roles of this project.",
+				Optional:            true,
+			},
 			"user_email": schema.StringAttribute{
 				MarkdownDescription: "Email of the user to filter escalations for. If not specified, all users' escalations will be returned.",
 				Optional:            true,
@@ -54,6 +110,23 @@ func (r *GsudoEscalationsDataSource) Schema(ctx context.Context, req datasource.
 	}
 }
 
+func (d *GsudoEscalationsDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*ravelinProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ravelinProvider, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.provider = provider
+}
+
 func (d *GsudoEscalationsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
 	var rData models.GsudoEscalationsDataSourceModel
 
@@ -67,21 +140,17 @@ func (d *GsudoEscalationsDataSource) Read(ctx context.Context, req datasource.Re
 		return
 	}
 
-	userFiles, err := iam.GetUserFiles(iamPath)
+	dir, err := loadIamDirectory(d.provider, iamPath)
 	if err != nil {
-		resp.Diagnostics.AddError("failed to retrieve user files", err.Error())
+		addIamErrors(&resp.Diagnostics, "failed to load IAM directory", err)
 		return
 	}
+	addDirectoryWarnings(&resp.Diagnostics, dir)
+	allAccess := dir.Entities(rData.IncludeServiceAccounts.ValueBool())
 
-	allUserAccess := make([]iam.RavelinAccess, 0, len(userFiles))
-	for _, userFile := range userFiles {
-		userAccess, err := iam.ExtractRavelinAccess(fmt.Sprintf("%s/users/%s", iamPath, userFile))
-		if err != nil {
-			resp.Diagnostics.AddError("failed to extract user access", err.Error())
-			return
-		}
-
-		err = userAccess.InheritGsudoAccess()
+	allUserAccess := make([]iam.RavelinAccess, 0, len(allAccess))
+	for _, userAccess := range allAccess {
+		err := userAccess.InheritGsudoAccess()
 		if err != nil {
 			resp.Diagnostics.AddError("failed to inherit gsudo access", err.Error())
 			return
@@ -89,9 +158,23 @@ func (d *GsudoEscalationsDataSource) Read(ctx context.Context, req datasource.Re
 
 		allUserAccess = append(allUserAccess, userAccess)
 	}
+	groups := dir.Groups()
+
+	if project := rData.Project.ValueString(); project != "" {
+		filterProject(allUserAccess, project)
+		filterProject(groups, project)
+	}
+
+	byProject := convertByProjectToMap(append(slices.Clone(allUserAccess), groups...))
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("by_project"), byProject)...)
 
 	allEscalations := convertEscalationsToMap(ctx, allUserAccess, resp)
+	allExpiries := convertExpiriesToMap(allUserAccess)
 	accessPolicies := convertAccessPoliciesToMap(allUserAccess)
+	allDetails, err := convertDetailsToMap(dir, allUserAccess)
+	if err != nil {
+		resp.Diagnostics.AddError("failed to resolve escalation approvers", err.Error())
+	}
 
 	if resp.Diagnostics.HasError() {
 		resp.Diagnostics.AddError(
@@ -102,6 +185,21 @@ func (d *GsudoEscalationsDataSource) Read(ctx context.Context, req datasource.Re
 	}
 	resp.State.SetAttribute(ctx, path.Root("id"), types.StringValue(strconv.FormatInt(time.Now().Unix(), 10)))
 
+	identities, diags := identitiesToMap(ctx, allUserAccess, rData.UserEmail.ValueString())
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("identities"), identities)...)
+
+	explanations := types.MapNull(types.ObjectType{AttrTypes: models.GsudoExplanationAttrTypes})
+	if rData.Explain.ValueBool() {
+		explanations, diags = explainGsudoAccess(ctx, allUserAccess, rData.UserEmail.ValueString())
+		resp.Diagnostics.Append(diags...)
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("explanations"), explanations)...)
+
+	inactive, diags := inactiveToMap(ctx, dir, rData.IncludeInactive.ValueBool(), rData.IncludeServiceAccounts.ValueBool())
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("inactive"), inactive)...)
+
 	if emailFilter := rData.UserEmail.ValueString(); emailFilter != "" {
 		userEscalations := make(map[string]basetypes.MapValue, 1)
 		userAccessPolicies := make(map[string]types.Bool, 1)
@@ -111,17 +209,31 @@ func (d *GsudoEscalationsDataSource) Read(ctx context.Context, req datasource.Re
 			if escalation, foundEscalations := allEscalations[emailFilter]; foundEscalations {
 				userEscalations[emailFilter] = escalation
 			}
+			if expiries, foundExpiries := allExpiries[emailFilter]; foundExpiries {
+				allExpiries = map[string]map[string]map[string]string{emailFilter: expiries}
+			} else {
+				allExpiries = nil
+			}
+			if details, foundDetails := allDetails[emailFilter]; foundDetails {
+				allDetails = map[string]map[string]models.EscalationDetailsModel{emailFilter: details}
+			} else {
+				allDetails = nil
+			}
 		} else {
 			resp.Diagnostics.AddWarning(
 				"user email not found",
 				fmt.Sprintf("the specified user email '%s' was not found in the IAM users, returning empty results.", emailFilter),
 			)
+			allExpiries = nil
+			allDetails = nil
 		}
 
 		accessPoliciesVal, diags := types.MapValueFrom(ctx, types.BoolType, userAccessPolicies)
 		resp.Diagnostics.Append(diags...)
 		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("access_policies"), accessPoliciesVal)...)
 		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("escalations"), userEscalations)...)
+		resp.Diagnostics.Append(setExpiries(ctx, resp, allExpiries)...)
+		resp.Diagnostics.Append(setDetails(ctx, resp, allDetails)...)
 		return
 	}
 
@@ -129,6 +241,176 @@ func (d *GsudoEscalationsDataSource) Read(ctx context.Context, req datasource.Re
 	resp.Diagnostics.Append(diags...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("access_policies"), accessPoliciesVal)...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("escalations"), allEscalations)...)
+	resp.Diagnostics.Append(setExpiries(ctx, resp, allExpiries)...)
+	resp.Diagnostics.Append(setDetails(ctx, resp, allDetails)...)
+}
+
+// explainGsudoAccess returns the sources of the escalations and access policies
+// of the users. If email is not empty, only the matching user is kept.
+func explainGsudoAccess(ctx context.Context, allAccess []iam.RavelinAccess, email string) (types.Map, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	sourceType := types.ObjectType{AttrTypes: models.SourceAttrTypes}
+
+	explanations := make(map[string]attr.Value, len(allAccess))
+	for _, access := range allAccess {
+		if email != "" && access.Email != email {
+			continue
+		}
+
+		escalations := make(map[string]attr.Value, len(access.Gsudo.Sources))
+		for project, roles := range access.Gsudo.Sources {
+			roleSources := make(map[string]attr.Value, len(roles))
+			for role, sources := range roles {
+				values := make([]attr.Value, len(sources))
+				for i := range sources {
+					values[i] = sourceToObject(&sources[i])
+				}
+				roleSources[role] = types.ListValueMust(sourceType, values)
+			}
+			escalations[project] = types.MapValueMust(types.ListType{ElemType: sourceType}, roleSources)
+		}
+
+		explanation, d := types.ObjectValue(models.GsudoExplanationAttrTypes, map[string]attr.Value{
+			"escalations":     types.MapValueMust(types.MapType{ElemType: types.ListType{ElemType: sourceType}}, escalations),
+			"access_policies": sourceToObject(access.Gsudo.AccessPoliciesSource),
+		})
+		diags.Append(d...)
+		explanations[access.Email] = explanation
+	}
+
+	explained, d := types.MapValue(types.ObjectType{AttrTypes: models.GsudoExplanationAttrTypes}, explanations)
+	diags.Append(d...)
+	return explained, diags
+}
+
+// filterProject only keeps the escalations of the project.
+func filterProject(allAccess []iam.RavelinAccess, project string) {
+	for i := range allAccess {
+		gsudo := &allAccess[i].Gsudo
+		if roles, ok := gsudo.Escalations[project]; ok {
+			gsudo.Escalations = map[string][]string{project: roles}
+		} else {
+			gsudo.Escalations = map[string][]string{}
+		}
+		if expiries, ok := gsudo.Expiries[project]; ok {
+			gsudo.Expiries = map[string]map[string]time.Time{project: expiries}
+		} else {
+			gsudo.Expiries = nil
+		}
+		if sources, ok := gsudo.Sources[project]; ok {
+			gsudo.Sources = map[string]map[string][]iam.Source{project: sources}
+		} else {
+			gsudo.Sources = nil
+		}
+		if requirements, ok := gsudo.Requirements[project]; ok {
+			gsudo.Requirements = map[string]iam.EscalationRequirements{project: requirements}
+		} else {
+			gsudo.Requirements = nil
+		}
+	}
+}
+
+// convertByProjectToMap inverts the escalations to a map of projects to roles
+// to the sorted emails of the members granted the role.
+func convertByProjectToMap(allAccess []iam.RavelinAccess) map[string]map[string][]string {
+	byProject := make(map[string]map[string][]string)
+	for _, access := range allAccess {
+		for project, roles := range access.Gsudo.Escalations {
+			if byProject[project] == nil {
+				byProject[project] = make(map[string][]string)
+			}
+			for _, role := range roles {
+				byProject[project][role] = append(byProject[project][role], access.Email)
+			}
+		}
+	}
+	for _, roles := range byProject {
+		for role, members := range roles {
+			slices.Sort(members)
+			roles[role] = slices.Compact(members)
+		}
+	}
+	return byProject
+}
+
+// convertExpiriesToMap returns the RFC 3339 expiry of the escalation roles with
+// an expiry, by user email, project and role.
+func convertExpiriesToMap(userAccess []iam.RavelinAccess) map[string]map[string]map[string]string {
+	allExpiries := make(map[string]map[string]map[string]string)
+	for _, access := range userAccess {
+		for project, roles := range access.Gsudo.Expiries {
+			for role, expiry := range roles {
+				if allExpiries[access.Email] == nil {
+					allExpiries[access.Email] = make(map[string]map[string]string)
+				}
+				if allExpiries[access.Email][project] == nil {
+					allExpiries[access.Email][project] = make(map[string]string)
+				}
+				allExpiries[access.Email][project][role] = expiry.Format(time.RFC3339)
+			}
+		}
+	}
+	return allExpiries
+}
+
+func setExpiries(ctx context.Context, resp *datasource.ReadResponse, expiries map[string]map[string]map[string]string) diag.Diagnostics {
+	expiriesVal, diags := types.MapValueFrom(ctx, types.MapType{ElemType: types.MapType{ElemType: types.StringType}}, expiries)
+	if diags.HasError() {
+		return diags
+	}
+	return append(diags, resp.State.SetAttribute(ctx, path.Root("escalation_expiries"), expiriesVal)...)
+}
+
+// convertDetailsToMap returns the escalations of the users along with their
+// requirements, by user email and project. Group approvers are resolved to
+// their email.
+func convertDetailsToMap(dir *iam.Directory, userAccess []iam.RavelinAccess) (map[string]map[string]models.EscalationDetailsModel, error) {
+	allDetails := make(map[string]map[string]models.EscalationDetailsModel, len(userAccess))
+	for _, access := range userAccess {
+		if len(access.Gsudo.Escalations) == 0 {
+			continue
+		}
+
+		details := make(map[string]models.EscalationDetailsModel, len(access.Gsudo.Escalations))
+		for project, roles := range access.Gsudo.Escalations {
+			requirements := access.Gsudo.Requirements[project]
+
+			approvers := make([]string, 0, len(requirements.Approvers))
+			for _, approver := range requirements.Approvers {
+				if !strings.Contains(approver, "@") {
+					email, err := dir.GroupEmail(approver)
+					if err != nil {
+						return nil, fmt.Errorf("error determining email of approver group %s: %w", approver, err)
+					}
+					approver = email
+				}
+				approvers = append(approvers, approver)
+			}
+			slices.Sort(approvers)
+
+			maxDuration := types.StringNull()
+			if requirements.MaxDuration != 0 {
+				maxDuration = types.StringValue(requirements.MaxDuration.String())
+			}
+
+			details[project] = models.EscalationDetailsModel{
+				Roles:                 append([]string{}, roles...),
+				MaxDuration:           maxDuration,
+				RequiresJustification: requirements.RequiresJustification,
+				Approvers:             slices.Compact(approvers),
+			}
+		}
+		allDetails[access.Email] = details
+	}
+	return allDetails, nil
+}
+
+func setDetails(ctx context.Context, resp *datasource.ReadResponse, details map[string]map[string]models.EscalationDetailsModel) diag.Diagnostics {
+	detailsVal, diags := types.MapValueFrom(ctx, types.MapType{ElemType: types.ObjectType{AttrTypes: models.EscalationDetailsAttrTypes}}, details)
+	if diags.HasError() {
+		return diags
+	}
+	return append(diags, resp.State.SetAttribute(ctx, path.Root("escalation_details"), detailsVal)...)
 }
 
 // convertEscalationsToMap converts the escalations from the RavelinAccess
@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/ravelin-community/terraform-provider-ravelin/internal/models"
+	iam "github.com/ravelin-community/terraform-provider-ravelin/internal/ravelinaccess"
+)
+
+func TestConvertByProjectToMap(t *testing.T) {
+	allAccess := func() []iam.RavelinAccess {
+		return []iam.RavelinAccess{
+			{
+				Email: "john.doe@ravelin.com",
+				Gsudo: iam.GsudoAccess{Escalations: map[string][]string{
+					"prod-payments": {"roles/owner", "roles/viewer"},
+					"staging":       {"projects/staging/roles/deployer"},
+				}},
+			},
+			{
+				Email: "jane.doe@ravelin.com",
+				Gsudo: iam.GsudoAccess{Escalations: map[string][]string{
+					"prod-payments": {"roles/viewer"},
+				}},
+			},
+			{
+				Email: "gcp-payments@ravelin.com",
+				Type:  iam.GROUP,
+				Gsudo: iam.GsudoAccess{Escalations: map[string][]string{
+					"prod-payments": {"roles/viewer"},
+				}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		project  string
+		expected map[string]map[string][]string
+	}{
+		{
+			name: "all_projects",
+			expected: map[string]map[string][]string{
+				"prod-payments": {
+					"roles/owner":  {"john.doe@ravelin.com"},
+					"roles/viewer": {"gcp-payments@ravelin.com", "jane.doe@ravelin.com", "john.doe@ravelin.com"},
+				},
+				"staging": {
+					"projects/staging/roles/deployer": {"john.doe@ravelin.com"},
+				},
+			},
+		},
+		{
+			name:    "project_filter",
+			project: "staging",
+			expected: map[string]map[string][]string{
+				"staging": {
+					"projects/staging/roles/deployer": {"john.doe@ravelin.com"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			access := allAccess()
+			if tt.project != "" {
+				filterProject(access, tt.project)
+			}
+
+			if diff := cmp.Diff(tt.expected, convertByProjectToMap(access)); diff != "" {
+				t.Errorf("convertByProjectToMap() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExplainGsudoAccess(t *testing.T) {
+	allAccess := []iam.RavelinAccess{
+		{
+			Email: "john.doe@ravelin.com",
+			Gsudo: iam.GsudoAccess{
+				Sources: map[string]map[string][]iam.Source{
+					"my-project": {"roles/owner": {{File: "iam/users/john_doe.yml"}, {Group: "team", File: "iam/groups/team.yml"}}},
+				},
+				AccessPoliciesSource: &iam.Source{Group: "team", File: "iam/groups/team.yml"},
+			},
+		},
+		{Email: "jane.doe@ravelin.com"},
+	}
+
+	explanations, diags := explainGsudoAccess(context.Background(), allAccess, "john.doe@ravelin.com")
+	if diags.HasError() {
+		t.Fatalf("explainGsudoAccess() unexpected error: %v", diags)
+	}
+
+	var got map[string]struct {
+		Escalations    map[string]map[string][]models.SourceModel `tfsdk:"escalations"`
+		AccessPolicies *models.SourceModel                        `tfsdk:"access_policies"`
+	}
+	if diags := explanations.ElementsAs(context.Background(), &got, false); diags.HasError() {
+		t.Fatalf("ElementsAs() unexpected error: %v", diags)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("explainGsudoAccess() expected only the filtered user, got %v", got)
+	}
+	sources := got["john.doe@ravelin.com"].Escalations["my-project"]["roles/owner"]
+	if len(sources) != 2 || sources[0].Source.ValueString() != "user" || sources[1].Source.ValueString() != "group:team" {
+		t.Errorf("explainGsudoAccess() unexpected escalation sources %v", sources)
+	}
+	if got["john.doe@ravelin.com"].AccessPolicies.File.ValueString() != "iam/groups/team.yml" {
+		t.Errorf("explainGsudoAccess() unexpected access policies source %v", got["john.doe@ravelin.com"].AccessPolicies)
+	}
+}
+
+func TestConvertDetailsToMap(t *testing.T) {
+	iamPath := t.TempDir()
+	for path, data := range map[string]string{
+		"users/john_doe.yml":  "gsudo:\n  escalations:\n    my-project:\n      roles:\n        - roles/owner\n      max_duration: 90m\n      approvers:\n        - platform\n        - jane.doe@ravelin.com\n    other-project:\n      - roles/viewer\n",
+		"groups/platform.yml": "gsudo:\n  inherit: false\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(iamPath, path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(iamPath, path), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir, err := iam.LoadDirectory(iamPath, iam.Settings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	details, err := convertDetailsToMap(dir, dir.Entities(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]map[string]models.EscalationDetailsModel{
+		"john.doe@ravelin.com": {
+			"my-project": {
+				Roles:       []string{"roles/owner"},
+				MaxDuration: types.StringValue("1h30m0s"),
+				Approvers:   []string{"gcp-platform@ravelin.com", "jane.doe@ravelin.com"},
+			},
+			"other-project": {
+				Roles:       []string{"roles/viewer"},
+				MaxDuration: types.StringNull(),
+				Approvers:   []string{},
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, details); diff != "" {
+		t.Errorf("unexpected escalation details (-want +got):\n%s", diff)
+	}
+}
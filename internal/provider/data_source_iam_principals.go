@@ -0,0 +1,272 @@
+package provider
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/ravelin-community/terraform-provider-ravelin/internal/models"
+	iam "github.com/ravelin-community/terraform-provider-ravelin/internal/ravelinaccess"
+)
+
+// principalTypes are the valid values of the type filter.
+var principalTypes = []string{iam.USER.String(), iam.GROUP.String(), iam.SERVICE.String()}
+
+var _ datasource.DataSource = &IamPrincipalsDataSource{}
+
+type IamPrincipalsDataSource struct {
+	provider *ravelinProvider
+}
+
+func (r *IamPrincipalsDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_iam_principals"
+}
+
+func (r *IamPrincipalsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"iam_path": schema.StringAttribute{
+				MarkdownDescription: "Path to the root of the IAM directory containing user and group definitions",
+				Required:            true,
+			},
+			"type": schema.StringAttribute{
+				MarkdownDescription: "Type of the principals to return, one of `user`, `group` or `service_account`. If not specified, principals of every type are returned.",
+				Optional:            true,
+			},
+			"group": schema.StringAttribute{
+				MarkdownDescription: "Name of a group, e.g. `platform` for `groups/platform.yml`, to only return the principals belonging to it, directly or through nested groups.",
+				Optional:            true,
+			},
+			"team": schema.StringAttribute{
+				MarkdownDescription: "Team to only return the principals of.",
+				Optional:            true,
+			},
+			"email_regex": schema.StringAttribute{
+				MarkdownDescription: "Regular expression, in the Go syntax, to only return the principals whose email matches it.",
+				Optional:            true,
+			},
+			"principals": schema.ListAttribute{
+				MarkdownDescription: "Principals of the IAM directory matching the filters, sorted by email. Each principal has its `email`, " +
+					"`type`, its `status` and `offboarded_at` date, the `project` of service accounts, the `groups` listed in its file, the identity fields set in its file, " +
+					"null if unset, its `gcp_roles`, its effective `gsudo_escalations` and `gsudo_access_policies`, including the ones " +
+					"inherited from its groups, and its effective `twingate` access, shaped like the `twingate_access` of the " +
+					"`ravelin_twingate_access` data source. Groups are returned with their own settings.",
+				Computed:    true,
+				ElementType: types.ObjectType{AttrTypes: models.PrincipalAttrTypes},
+			},
+			"include_inactive": schema.BoolAttribute{
+				MarkdownDescription: "Return the suspended and offboarded principals matching the filters in `inactive_principals`. Defaults to `false`.",
+				Optional:            true,
+			},
+			"inactive_principals": schema.ListAttribute{
+				MarkdownDescription: "Suspended and offboarded principals matching the filters, sorted by email, only set when " +
+					"`include_inactive` is `true`. They have the same fields as `principals`, with the access they had.",
+				Computed:    true,
+				ElementType: types.ObjectType{AttrTypes: models.PrincipalAttrTypes},
+			},
+			"id": schema.StringAttribute{
+				MarkdownDescription: "Hash of the returned principals, only changing when they do.",
+				Computed:            true,
+			},
+		},
+		MarkdownDescription: "Get the users, groups and service accounts of the IAM directory with their effective access.\n\n" +
+			"Use this data source to list principals, e.g. the engineers of a team, rather than parsing the IAM files in Terraform.",
+	}
+}
+
+func (d *IamPrincipalsDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*ravelinProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ravelinProvider, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.provider = provider
+}
+
+func (d *IamPrincipalsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data models.IamPrincipalsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	filter := principalFilter{
+		typ:   data.Type.ValueString(),
+		group: data.Group.ValueString(),
+		team:  data.Team.ValueString(),
+	}
+	if filter.typ != "" && !slices.Contains(principalTypes, filter.typ) {
+		resp.Diagnostics.AddError("invalid type", fmt.Sprintf("`type` must be one of %s, got %q", strings.Join(principalTypes, ", "), filter.typ))
+		return
+	}
+	if expr := data.EmailRegex.ValueString(); expr != "" {
+		var err error
+		if filter.email, err = regexp.Compile(expr); err != nil {
+			resp.Diagnostics.AddError("invalid email_regex", err.Error())
+			return
+		}
+	}
+
+	dir, err := loadIamDirectory(d.provider, data.IamPath.ValueString())
+	if err != nil {
+		addIamErrors(&resp.Diagnostics, "failed to load IAM directory", err)
+		return
+	}
+	addDirectoryWarnings(&resp.Diagnostics, dir)
+
+	principals, err := iamPrincipals(append(dir.Entities(true), dir.Groups()...), filter)
+	if err != nil {
+		resp.Diagnostics.AddError("failed to resolve IAM principals", err.Error())
+		return
+	}
+
+	var diags diag.Diagnostics
+	data.Principals, diags = principalsToList(ctx, principals)
+	resp.Diagnostics.Append(diags...)
+
+	data.InactivePrincipals = types.ListNull(types.ObjectType{AttrTypes: models.PrincipalAttrTypes})
+	if data.IncludeInactive.ValueBool() {
+		inactive, err := iamPrincipals(dir.Inactive(true), filter)
+		if err != nil {
+			resp.Diagnostics.AddError("failed to resolve inactive IAM principals", err.Error())
+			return
+		}
+		data.InactivePrincipals, diags = principalsToList(ctx, inactive)
+		resp.Diagnostics.Append(diags...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.Id = types.StringValue(contentID(data.Principals, data.InactivePrincipals))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// principalFilter selects the principals returned by the data source, empty
+// fields matching every principal.
+type principalFilter struct {
+	typ   string
+	group string
+	team  string
+	email *regexp.Regexp
+}
+
+func (f principalFilter) match(principal *iam.RavelinAccess) (bool, error) {
+	switch {
+	case f.typ != "" && principal.Type.String() != f.typ:
+		return false, nil
+	case f.team != "" && principal.Team != f.team:
+		return false, nil
+	case f.email != nil && !f.email.MatchString(principal.Email):
+		return false, nil
+	case f.group == "":
+		return true, nil
+	}
+
+	groups, err := principal.AllGroups()
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(groups, f.group), nil
+}
+
+// iamPrincipals returns the principals matching the filter, sorted by email,
+// with the access inherited from their groups.
+func iamPrincipals(allAccess []iam.RavelinAccess, filter principalFilter) ([]iam.RavelinAccess, error) {
+	var principals []iam.RavelinAccess
+	for _, principal := range allAccess {
+		ok, err := filter.match(&principal)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		// groups are returned with their own settings, only users and service
+		// accounts inherit access
+		if principal.Type != iam.GROUP {
+			if err := principal.InheritGsudoAccess(); err != nil {
+				return nil, err
+			}
+			if err := principal.InheritTwingateAccess(); err != nil {
+				return nil, err
+			}
+		}
+		principals = append(principals, principal)
+	}
+
+	slices.SortStableFunc(principals, func(a, b iam.RavelinAccess) int { return cmp.Compare(a.Email, b.Email) })
+	return principals, nil
+}
+
+// contentID returns a hash of the values, used as the ID of data sources so
+// that it only changes with their content. The string representation of
+// Terraform values is consistent, maps and objects being sorted by key.
+func contentID(values ...attr.Value) string {
+	h := sha256.New()
+	for _, v := range values {
+		h.Write([]byte(v.String()))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func principalsToList(ctx context.Context, principals []iam.RavelinAccess) (types.List, diag.Diagnostics) {
+	principalModels := make([]models.PrincipalModel, len(principals))
+	for i, principal := range principals {
+		principalModels[i] = newPrincipalModel(principal)
+	}
+	return types.ListValueFrom(ctx, types.ObjectType{AttrTypes: models.PrincipalAttrTypes}, principalModels)
+}
+
+func newPrincipalModel(principal iam.RavelinAccess) models.PrincipalModel {
+	escalations := make(map[string][]string, len(principal.Gsudo.Escalations))
+	for project, roles := range principal.Gsudo.Escalations {
+		escalations[project] = append([]string{}, roles...)
+	}
+	roles := make(map[string][]string, len(principal.GCP.Roles))
+	for project, projectRoles := range principal.GCP.Roles {
+		roles[project] = append([]string{}, projectRoles...)
+	}
+
+	return models.PrincipalModel{
+		Email:   principal.Email,
+		Type:    principal.Type.String(),
+		Project: stringOrNull(principal.Project),
+		Groups:  append([]string{}, principal.GCP.Groups...),
+
+		Status:       cmp.Or(principal.Status, iam.StatusActive),
+		OffboardedAt: stringOrNull(principal.OffboardedAt),
+
+		DisplayName:    stringOrNull(principal.DisplayName),
+		Team:           stringOrNull(principal.Team),
+		Manager:        stringOrNull(principal.Manager),
+		EmploymentType: stringOrNull(principal.EmploymentType),
+		StartDate:      stringOrNull(principal.StartDate),
+
+		GcpRoles:            roles,
+		GsudoEscalations:    escalations,
+		GsudoAccessPolicies: principal.Gsudo.AccessPolicies != nil && *principal.Gsudo.AccessPolicies,
+		Twingate:            newTwingateAccessModel(principal.Twingate),
+	}
+}
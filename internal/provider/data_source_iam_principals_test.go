@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/ravelin-community/terraform-provider-ravelin/internal/models"
+	iam "github.com/ravelin-community/terraform-provider-ravelin/internal/ravelinaccess"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIamPrincipals(t *testing.T) {
+	iamPath := t.TempDir()
+	for path, data := range map[string]string{
+		"users/john_doe.yml":                     "team: payments\ngcp:\n  groups:\n    - team\ngsudo:\n  inherit: true\n",
+		"users/jane_doe.yml":                     "team: platform\n",
+		"groups/team.yml":                        "gcp:\n  groups:\n    - engineering\ngsudo:\n  escalations:\n    my-project:\n      - roles/viewer\n",
+		"groups/engineering.yml":                 "twingate:\n  enabled: true\n",
+		"service-accounts/my-project/ci-bot.yml": "gcp:\n  groups:\n    - engineering\n",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(iamPath, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(iamPath, path), []byte(data), 0644))
+	}
+	dir, err := iam.LoadDirectory(iamPath, iam.Settings{})
+	require.NoError(t, err)
+	allAccess := append(dir.Entities(true), dir.Groups()...)
+
+	tests := []struct {
+		name     string
+		filter   principalFilter
+		expected []string
+	}{
+		{
+			name: "all",
+			expected: []string{
+				"ci-bot@my-project.iam.gserviceaccount.com",
+				"gcp-engineering@ravelin.com",
+				"gcp-team@ravelin.com",
+				"jane.doe@ravelin.com",
+				"john.doe@ravelin.com",
+			},
+		},
+		{
+			name:     "type",
+			filter:   principalFilter{typ: "group"},
+			expected: []string{"gcp-engineering@ravelin.com", "gcp-team@ravelin.com"},
+		},
+		{
+			name:     "nested_group",
+			filter:   principalFilter{group: "engineering"},
+			expected: []string{"ci-bot@my-project.iam.gserviceaccount.com", "gcp-team@ravelin.com", "john.doe@ravelin.com"},
+		},
+		{
+			name:     "team_and_email",
+			filter:   principalFilter{team: "payments", email: regexp.MustCompile(`^john\.`)},
+			expected: []string{"john.doe@ravelin.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principals, err := iamPrincipals(allAccess, tt.filter)
+			require.NoError(t, err)
+
+			var emails []string
+			for _, principal := range principals {
+				emails = append(emails, principal.Email)
+			}
+			require.Equal(t, tt.expected, emails)
+		})
+	}
+
+	// principals have their effective access
+	principals, err := iamPrincipals(allAccess, principalFilter{email: regexp.MustCompile(`^john\.`)})
+	require.NoError(t, err)
+	principal := newPrincipalModel(principals[0])
+	require.Equal(t, "user", principal.Type)
+	require.Equal(t, map[string][]string{"my-project": {"roles/viewer"}}, principal.GsudoEscalations)
+	require.True(t, principal.Twingate.Enabled)
+
+	// IDs only depend on the content
+	ids := make(map[string]bool)
+	for range 5 {
+		principals, err := iamPrincipals(allAccess, principalFilter{})
+		require.NoError(t, err)
+		principalModels := make([]models.PrincipalModel, len(principals))
+		for i, principal := range principals {
+			principalModels[i] = newPrincipalModel(principal)
+		}
+		list, diags := types.ListValueFrom(context.Background(), types.ObjectType{AttrTypes: models.PrincipalAttrTypes}, principalModels)
+		require.False(t, diags.HasError())
+		ids[contentID(list)] = true
+	}
+	require.Len(t, ids, 1)
+}
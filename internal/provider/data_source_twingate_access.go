@@ -3,6 +3,7 @@ package provider
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
 	"time"
 
@@ -14,7 +15,9 @@ import (
 	iam "github.com/ravelin-community/terraform-provider-ravelin/internal/ravelinaccess"
 )
 
-type TwingateAccessDataSource struct{}
+type TwingateAccessDataSource struct {
+	provider *ravelinProvider
+}
 
 func (r *TwingateAccessDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_twingate_access"
@@ -28,14 +31,41 @@ func (r *TwingateAccessDataSource) Schema(ctx context.Context, req datasource.Sc
 				Required:            true,
 			},
 			"twingate_access": schema.MapAttribute{
-				MarkdownDescription: "Map of users to Twingate access. The key is the user email and the value is an object of Twingate access details.",
-				Computed:            true,
-				ElementType: types.ObjectType{
-					AttrTypes: map[string]attr.Type{
-						"enabled": types.BoolType,
-						"admin":   types.BoolType,
-					},
-				},
+				MarkdownDescription: "Map of users to Twingate access. The key is the user email and the value is an object of Twingate access details, " +
+					"`expires` being the RFC 3339 effective expiry of the access, null if it doesn't expire. `groups` and `resources` " +
+					"are the sorted Twingate groups and Resources of the user, including those inherited from all its groups. Set `explain` " +
+					"to get the `user` or `group:<name>` supplying the `enabled` and `admin` values in `explanations`.",
+				Computed:    true,
+				ElementType: types.ObjectType{AttrTypes: models.TwingateAccessAttrTypes},
+			},
+			"include_service_accounts": schema.BoolAttribute{
+				MarkdownDescription: "Include the service accounts defined in the `service-accounts` directory of the IAM directory, keyed by their email. Defaults to `false`.",
+				Optional:            true,
+			},
+			"identities":       identitiesAttribute(),
+			"explain":          explainAttribute(),
+			"include_inactive": includeInactiveAttribute(),
+			"inactive":         inactiveAttribute(),
+			"explanations": schema.MapAttribute{
+				MarkdownDescription: "Map of users to the source of their Twingate access, only set when `explain` is `true`. The key is the " +
+					"user email and the value is an object with the sources deciding `enabled` and `admin`, null if no file sets them. Sources " +
+					"are objects with the `source`, `user` or `group:<name>`, and the `file` setting the value.",
+				Computed:    true,
+				ElementType: types.ObjectType{AttrTypes: models.TwingateExplanationAttrTypes},
+			},
+			"twingate_groups": schema.MapAttribute{
+				MarkdownDescription: "Map of Twingate groups to their members, shaped to feed `twingate_group` resources. The key is the " +
+					"Twingate group name and the value is the sorted emails of the users with Twingate access belonging to it, directly or " +
+					"through their groups. The `user_email` filter doesn't apply.",
+				Computed:    true,
+				ElementType: types.ListType{ElemType: types.StringType},
+			},
+			"twingate_resources": schema.MapAttribute{
+				MarkdownDescription: "Map of Twingate Resources to the groups allowed to access them, shaped to feed the access of " +
+					"`twingate_resource` resources. The key is the Resource name and the value is the sorted Twingate groups listed alongside " +
+					"the Resource in the IAM files. The `user_email` filter doesn't apply.",
+				Computed:    true,
+				ElementType: types.ListType{ElemType: types.StringType},
 			},
 			"user_email": schema.StringAttribute{
 				MarkdownDescription: "Email of the user to retrieve twingate access for. If not specified, all users access is returned.",
@@ -49,6 +79,23 @@ func (r *TwingateAccessDataSource) Schema(ctx context.Context, req datasource.Sc
 	}
 }
 
+func (d *TwingateAccessDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*ravelinProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ravelinProvider, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.provider = provider
+}
+
 func (d *TwingateAccessDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
 	var data models.TwingateAccessDataSourceModel
 
@@ -62,22 +109,17 @@ func (d *TwingateAccessDataSource) Read(ctx context.Context, req datasource.Read
 		return
 	}
 
-	userFiles, err := iam.GetUserFiles(iamPath)
+	dir, err := loadIamDirectory(d.provider, iamPath)
 	if err != nil {
-		resp.Diagnostics.AddError("failed to retrieve user files", err.Error())
+		addIamErrors(&resp.Diagnostics, "failed to load IAM directory", err)
 		return
 	}
+	addDirectoryWarnings(&resp.Diagnostics, dir)
+	allAccess := dir.Entities(data.IncludeServiceAccounts.ValueBool())
 
-	allUserAccess := make([]iam.RavelinAccess, 0, len(userFiles))
-
-	for _, userFile := range userFiles {
-		userAccess, err := iam.ExtractRavelinAccess(fmt.Sprintf("%s/users/%s", iamPath, userFile))
-		if err != nil {
-			resp.Diagnostics.AddError("failed to extract user access", err.Error())
-			return
-		}
-
-		err = userAccess.InheritTwingateAccess()
+	allUserAccess := make([]iam.RavelinAccess, 0, len(allAccess))
+	for _, userAccess := range allAccess {
+		err := userAccess.InheritTwingateAccess()
 		if err != nil {
 			resp.Diagnostics.AddError("failed to inherit twingate access", err.Error())
 			return
@@ -95,17 +137,18 @@ func (d *TwingateAccessDataSource) Read(ctx context.Context, req datasource.Read
 	}
 
 	twingateAccess := make(map[string]models.TwingateAccessModel)
+	twingateGroups := make(map[string][]string)
 	for _, userAccess := range allUserAccess {
 		if *userAccess.Twingate.Enabled {
+			for _, group := range userAccess.Twingate.Groups {
+				twingateGroups[group] = append(twingateGroups[group], userAccess.Email)
+			}
 
 			if !data.UserEmail.IsNull() && data.UserEmail.ValueString() != userAccess.Email {
 				continue // Skip if user email does not match
 			}
 
-			twingateAccess[userAccess.Email] = models.TwingateAccessModel{
-				Enabled: *userAccess.Twingate.Enabled,
-				Admin:   *userAccess.Twingate.Admin,
-			}
+			twingateAccess[userAccess.Email] = newTwingateAccessModel(userAccess.Twingate)
 		}
 	}
 
@@ -115,7 +158,61 @@ func (d *TwingateAccessDataSource) Read(ctx context.Context, req datasource.Read
 		return
 	}
 	data.TwingateAccess = dataTwingateAccess
+
+	for _, members := range twingateGroups {
+		slices.Sort(members)
+	}
+	data.TwingateGroups, diags = types.MapValueFrom(ctx, types.ListType{ElemType: types.StringType}, twingateGroups)
+	resp.Diagnostics.Append(diags...)
+	data.TwingateResources, diags = types.MapValueFrom(ctx, types.ListType{ElemType: types.StringType}, dir.TwingateResources())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Identities, diags = identitiesToMap(ctx, allUserAccess, data.UserEmail.ValueString())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.Inactive, diags = inactiveToMap(ctx, dir, data.IncludeInactive.ValueBool(), data.IncludeServiceAccounts.ValueBool())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.Explanations = types.MapNull(types.ObjectType{AttrTypes: models.TwingateExplanationAttrTypes})
+	if data.Explain.ValueBool() {
+		explanations := make(map[string]attr.Value, len(twingateAccess))
+		for _, userAccess := range allUserAccess {
+			if _, ok := twingateAccess[userAccess.Email]; !ok {
+				continue
+			}
+			explanations[userAccess.Email] = types.ObjectValueMust(models.TwingateExplanationAttrTypes, map[string]attr.Value{
+				"enabled": sourceToObject(userAccess.Twingate.EnabledSource),
+				"admin":   sourceToObject(userAccess.Twingate.AdminSource),
+			})
+		}
+		data.Explanations, diags = types.MapValue(types.ObjectType{AttrTypes: models.TwingateExplanationAttrTypes}, explanations)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	data.Id = types.StringValue(strconv.FormatInt(time.Now().Unix(), 10))
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
+
+// newTwingateAccessModel converts the Twingate access, settings which are not
+// set being false.
+func newTwingateAccessModel(access iam.TwingateAccess) models.TwingateAccessModel {
+	return models.TwingateAccessModel{
+		Enabled: access.Enabled != nil && *access.Enabled,
+		Admin:   access.Admin != nil && *access.Admin,
+		Expires: expiryOrNull(access.ExpiresAt),
+
+		Groups:    append([]string{}, access.Groups...),
+		Resources: append([]string{}, access.Resources...),
+	}
+}
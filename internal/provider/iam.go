@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/ravelin-community/terraform-provider-ravelin/internal/models"
+	iam "github.com/ravelin-community/terraform-provider-ravelin/internal/ravelinaccess"
+)
+
+// identitiesAttribute is the schema of the identity fields of the IAM files,
+// shared by the IAM data sources.
+func identitiesAttribute() schema.MapAttribute {
+	return schema.MapAttribute{
+		MarkdownDescription: "Map of users to their identity fields. The key is the user email and the value is an object " +
+			"with the `display_name`, `team`, `manager`, `employment_type` and `start_date` set in the IAM file, null if unset.",
+		Computed:    true,
+		ElementType: types.ObjectType{AttrTypes: models.IdentityAttrTypes},
+	}
+}
+
+// identitiesToMap converts the identity fields of the access to the native
+// terraform types. If email is not empty, only the matching identity is kept.
+func identitiesToMap(ctx context.Context, allAccess []iam.RavelinAccess, email string) (types.Map, diag.Diagnostics) {
+	identities := make(map[string]models.IdentityModel, len(allAccess))
+	for _, access := range allAccess {
+		if email != "" && access.Email != email {
+			continue
+		}
+		identities[access.Email] = models.IdentityModel{
+			DisplayName:    stringOrNull(access.DisplayName),
+			Team:           stringOrNull(access.Team),
+			Manager:        stringOrNull(access.Manager),
+			EmploymentType: stringOrNull(access.EmploymentType),
+			StartDate:      stringOrNull(access.StartDate),
+		}
+	}
+	return types.MapValueFrom(ctx, types.ObjectType{AttrTypes: models.IdentityAttrTypes}, identities)
+}
+
+// includeInactiveAttribute is the schema of the include_inactive flag of the IAM
+// data sources.
+func includeInactiveAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		MarkdownDescription: "Return the suspended and offboarded users, groups and service accounts in `inactive`. They are never granted access. Defaults to `false`.",
+		Optional:            true,
+	}
+}
+
+// inactiveAttribute is the schema of the inactive entities of the IAM data
+// sources.
+func inactiveAttribute() schema.MapAttribute {
+	return schema.MapAttribute{
+		MarkdownDescription: "Map of the suspended and offboarded users, groups and service accounts, only set when `include_inactive` is " +
+			"`true`. The key is the email and the value is an object with the `status`, the `offboarded_at` date, null if unset, and " +
+			"the `groups` and `twingate_groups` they belonged to, so that their memberships can be revoked.",
+		Computed:    true,
+		ElementType: types.ObjectType{AttrTypes: models.InactiveAttrTypes},
+	}
+}
+
+// inactiveToMap converts the inactive entities of the directory, null if
+// include is not set. Service accounts are only included with
+// includeServiceAccounts.
+func inactiveToMap(ctx context.Context, dir *iam.Directory, include, includeServiceAccounts bool) (types.Map, diag.Diagnostics) {
+	if !include {
+		return types.MapNull(types.ObjectType{AttrTypes: models.InactiveAttrTypes}), nil
+	}
+
+	var diags diag.Diagnostics
+	inactive := make(map[string]models.InactiveModel)
+	for _, entity := range dir.Inactive(includeServiceAccounts) {
+		// the Twingate groups users and service accounts had through their groups
+		if entity.Type != iam.GROUP {
+			if err := entity.InheritTwingateAccess(); err != nil {
+				diags.AddError("failed to inherit twingate access", err.Error())
+				continue
+			}
+		}
+		inactive[entity.Email] = models.InactiveModel{
+			Status:         entity.Status,
+			OffboardedAt:   stringOrNull(entity.OffboardedAt),
+			Groups:         append([]string{}, entity.GCP.Groups...),
+			TwingateGroups: append([]string{}, entity.Twingate.Groups...),
+		}
+	}
+	if diags.HasError() {
+		return types.MapNull(types.ObjectType{AttrTypes: models.InactiveAttrTypes}), diags
+	}
+	return types.MapValueFrom(ctx, types.ObjectType{AttrTypes: models.InactiveAttrTypes}, inactive)
+}
+
+// explainAttribute is the schema of the explain flag of the IAM data sources.
+func explainAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		MarkdownDescription: "Return in `explanations` where the effective access of each user comes from. Defaults to `false`.",
+		Optional:            true,
+	}
+}
+
+// sourceToObject converts the source of a grant or setting, null if no file
+// decides it.
+func sourceToObject(source *iam.Source) types.Object {
+	if source == nil {
+		return types.ObjectNull(models.SourceAttrTypes)
+	}
+	return types.ObjectValueMust(models.SourceAttrTypes, map[string]attr.Value{
+		"source": types.StringValue(source.String()),
+		"file":   types.StringValue(source.File),
+	})
+}
+
+// iamMember returns the IAM member of the user, group or service account, e.g.
+// `user:john.doe@ravelin.com`.
+func iamMember(access iam.RavelinAccess) string {
+	switch access.Type {
+	case iam.GROUP:
+		return "group:" + access.Email
+	case iam.SERVICE:
+		return "serviceAccount:" + access.Email
+	}
+	return "user:" + access.Email
+}
+
+// addIamErrors adds a diagnostic for every problem joined in err, so that all the
+// invalid IAM files are reported in a single run.
+func addIamErrors(diags *diag.Diagnostics, summary string, err error) {
+	for _, e := range iam.Errors(err) {
+		diags.AddError(summary, e.Error())
+	}
+}
+
+// addDirectoryWarnings adds a warning for every entry of the IAM directory
+// expiring soon and for every violation of its audited policies.
+func addDirectoryWarnings(diags *diag.Diagnostics, dir *iam.Directory) {
+	for _, warning := range dir.Warnings() {
+		diags.AddWarning("IAM access expiring soon", warning)
+	}
+	for _, violation := range dir.PolicyViolations() {
+		diags.AddWarning("IAM policy violated", violation)
+	}
+}
+
+// expiryOrNull returns the expiry in the RFC 3339 format, null for the zero time.
+func expiryOrNull(t time.Time) types.String {
+	if t.IsZero() {
+		return types.StringNull()
+	}
+	return types.StringValue(t.Format(time.RFC3339))
+}
+
+func stringOrNull(value string) types.String {
+	if value == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(value)
+}
@@ -2,23 +2,50 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	iam "github.com/ravelin-community/terraform-provider-ravelin/internal/ravelinaccess"
 )
 
 var _ provider.Provider = &ravelinProvider{}
 
 type ravelinProvider struct {
-	version string
-	project string
+	version     string
+	project     string
+	iamSettings iam.Settings
+
+	// directories memoises the parsed IAM directories per path, so data sources
+	// reading the same directory during a run only parse it once.
+	directoriesMu sync.Mutex
+	directories   map[string]*iam.Directory
 }
 
 type ravelinProviderModel struct {
-	Project types.String `tfsdk:"project"`
+	Project     types.String      `tfsdk:"project"`
+	IamSettings *iamSettingsModel `tfsdk:"iam_settings"`
+}
+
+type iamSettingsModel struct {
+	UserDomain      types.String `tfsdk:"user_domain"`
+	GroupDomain     types.String `tfsdk:"group_domain"`
+	GroupPrefix     types.String `tfsdk:"group_prefix"`
+	GroupSuffix     types.String `tfsdk:"group_suffix"`
+	UserSeparator   types.String `tfsdk:"user_separator"`
+	DomainOverrides types.Map    `tfsdk:"domain_overrides"`
+	MaxGroupDepth   types.Int64  `tfsdk:"max_group_depth"`
+
+	ExpiryWarningDays types.Int64 `tfsdk:"expiry_warning_days"`
+
+	TwingateEnabledMerge types.String `tfsdk:"twingate_enabled_merge"`
+	TwingateAdminMerge   types.String `tfsdk:"twingate_admin_merge"`
 }
 
 func (p *ravelinProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -34,6 +61,61 @@ func (p *ravelinProvider) Schema(_ context.Context, _ provider.SchemaRequest, re
 				Optional:            true,
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"iam_settings": schema.SingleNestedBlock{
+				MarkdownDescription: "Settings of the IAM data sources, mostly the naming rules used to derive emails from the IAM YAML files. " +
+					"Settings can also be defined in a `config.yml` file at the root of the IAM directory, the settings of " +
+					"the provider take precedence.",
+				Attributes: map[string]schema.Attribute{
+					"twingate_admin_merge": schema.StringAttribute{
+						MarkdownDescription: "Strategy merging the Twingate `admin` setting inherited from groups, one of `primary`, `any` or `all`. Defaults to `primary`.",
+						Optional:            true,
+					},
+					"twingate_enabled_merge": schema.StringAttribute{
+						MarkdownDescription: "Strategy merging the Twingate `enabled` setting inherited from groups: `primary` takes the setting of the " +
+							"first group of the user, or of the nearest group it belongs to setting it, `any` grants access if any group of the user " +
+							"grants it and `all` if every group of the user setting it grants it. Defaults to `primary`.",
+						Optional: true,
+					},
+					"user_domain": schema.StringAttribute{
+						MarkdownDescription: "Email domain of users. Defaults to `ravelin.com`.",
+						Optional:            true,
+					},
+					"group_domain": schema.StringAttribute{
+						MarkdownDescription: "Email domain of groups. Defaults to the user domain.",
+						Optional:            true,
+					},
+					"group_prefix": schema.StringAttribute{
+						MarkdownDescription: "Prefix prepended to the group file name to build the group email. Defaults to `gcp-`.",
+						Optional:            true,
+					},
+					"group_suffix": schema.StringAttribute{
+						MarkdownDescription: "Suffix appended to the group file name to build the group email.",
+						Optional:            true,
+					},
+					"user_separator": schema.StringAttribute{
+						MarkdownDescription: "Separator replacing the underscores of user file names to build the local part " +
+							"of user emails, e.g. `john_doe.yml` becomes `john.doe`. Defaults to `.`.",
+						Optional: true,
+					},
+					"domain_overrides": schema.MapAttribute{
+						MarkdownDescription: "Map of IAM subdirectories, e.g. `users/contractors`, to the email domain of the " +
+							"users or groups they contain. Nested directories use the override of their closest parent.",
+						Optional:    true,
+						ElementType: types.StringType,
+					},
+					"expiry_warning_days": schema.Int64Attribute{
+						MarkdownDescription: "Number of days before their expiry escalations, group memberships and Twingate access " +
+							"with an `expires` date are reported as warnings. Defaults to `14`.",
+						Optional: true,
+					},
+					"max_group_depth": schema.Int64Attribute{
+						MarkdownDescription: "Maximum nesting depth of groups listing other `groups`, direct groups being at depth 1. Defaults to `10`.",
+						Optional:            true,
+					},
+				},
+			},
+		},
 	}
 }
 
@@ -50,6 +132,41 @@ func (p *ravelinProvider) Configure(ctx context.Context, req provider.ConfigureR
 		p.project = config.Project.ValueString()
 	}
 
+	if config.IamSettings != nil {
+		settings := config.IamSettings
+		p.iamSettings = iam.Settings{
+			UserDomain:  settings.UserDomain.ValueString(),
+			GroupDomain: settings.GroupDomain.ValueString(),
+			GroupPrefix: settings.GroupPrefix.ValueStringPointer(),
+			GroupSuffix: settings.GroupSuffix.ValueString(),
+			// empty strings are valid prefixes and separators, only null values
+			// fall back to the defaults
+			UserSeparator: settings.UserSeparator.ValueStringPointer(),
+			MaxGroupDepth: int(settings.MaxGroupDepth.ValueInt64()),
+
+			ExpiryWarningDays: int(settings.ExpiryWarningDays.ValueInt64()),
+			TwingateMerge: iam.TwingateMerge{
+				Enabled: settings.TwingateEnabledMerge.ValueString(),
+				Admin:   settings.TwingateAdminMerge.ValueString(),
+			},
+		}
+		for attribute, strategy := range map[string]string{
+			"twingate_enabled_merge": p.iamSettings.TwingateMerge.Enabled,
+			"twingate_admin_merge":   p.iamSettings.TwingateMerge.Admin,
+		} {
+			if strategy != "" && !slices.Contains(iam.MergeStrategies, strategy) {
+				resp.Diagnostics.AddError(
+					"invalid "+attribute,
+					fmt.Sprintf("`%s` must be one of %s, got %q", attribute, strings.Join(iam.MergeStrategies, ", "), strategy),
+				)
+			}
+		}
+		resp.Diagnostics.Append(settings.DomainOverrides.ElementsAs(ctx, &p.iamSettings.DomainOverrides, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	// Make the provider available to data sources and resources
 	resp.DataSourceData = p
 	resp.ResourceData = p
@@ -74,12 +191,48 @@ func (p *ravelinProvider) DataSources(_ context.Context) []func() datasource.Dat
 		func() datasource.DataSource {
 			return &GsudoEscalationsDataSource{}
 		},
+		func() datasource.DataSource {
+			return &GsudoBindingsDataSource{}
+		},
+		func() datasource.DataSource {
+			return &GcpBindingsDataSource{}
+		},
+		func() datasource.DataSource {
+			return &GroupMembershipsDataSource{}
+		},
+		func() datasource.DataSource {
+			return &IamPrincipalsDataSource{}
+		},
 		func() datasource.DataSource {
 			return &TwingateAccessDataSource{}
 		},
 	}
 }
 
+// loadIamDirectory returns the parsed IAM directory at iamPath. Directories are
+// memoised on the provider for the length of the Terraform run.
+func loadIamDirectory(p *ravelinProvider, iamPath string) (*iam.Directory, error) {
+	if p == nil {
+		return iam.LoadDirectory(iamPath, iam.Settings{})
+	}
+
+	p.directoriesMu.Lock()
+	defer p.directoriesMu.Unlock()
+
+	if dir, ok := p.directories[iamPath]; ok {
+		return dir, nil
+	}
+	dir, err := iam.LoadDirectory(iamPath, p.iamSettings)
+	if err != nil {
+		return nil, err
+	}
+	if p.directories == nil {
+		p.directories = make(map[string]*iam.Directory)
+	}
+	p.directories[iamPath] = dir
+	return dir, nil
+}
+
 func New(version string) func() provider.Provider {
 	return func() provider.Provider {
 		return &ravelinProvider{
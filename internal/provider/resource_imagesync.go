@@ -2,8 +2,11 @@ package provider
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
@@ -12,10 +15,14 @@ import (
 	"github.com/ravelin-community/terraform-provider-ravelin/internal/image"
 	"github.com/ravelin-community/terraform-provider-ravelin/internal/models"
 	"github.com/ravelin-community/terraform-provider-ravelin/internal/planmodifiers"
+	"github.com/ravelin-community/terraform-provider-ravelin/internal/sbom"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -24,6 +31,7 @@ import (
 var (
 	_ resource.Resource                = &ImageSyncResource{}
 	_ resource.ResourceWithImportState = &ImageSyncResource{}
+	_ resource.ResourceWithModifyPlan  = &ImageSyncResource{}
 )
 
 type ImageSyncResource struct{}
@@ -36,12 +44,15 @@ func (r *ImageSyncResource) Schema(ctx context.Context, req resource.SchemaReque
 	resp.Schema = schema.Schema{
 		Attributes: map[string]schema.Attribute{
 			"source": schema.StringAttribute{
-				MarkdownDescription: "Repository reference to the source image you wish to mirror",
-				Required:            true,
+				MarkdownDescription: "Repository reference to the source image you wish to mirror. Images can also be loaded " +
+					"from the local filesystem using `oci-layout:///path/to/layout@sha256:...` for OCI image layout directories " +
+					"or `docker-archive:///path/to/image.tar` for tarballs produced by `docker save`.",
+				Required: true,
 			},
 			"destination": schema.StringAttribute{
-				MarkdownDescription: "Repository reference to the source image that you wish to mirror",
-				Required:            true,
+				MarkdownDescription: "Repository reference to the source image that you wish to mirror. Use " +
+					"`oci-layout:///path/to/layout:tag` to export the image to an OCI image layout directory instead, e.g. as a backup.",
+				Required: true,
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.RequiresReplace(),
 				},
@@ -57,6 +68,51 @@ func (r *ImageSyncResource) Schema(ctx context.Context, req resource.SchemaReque
 				MarkdownDescription: "GCP KMS key resource ID used to cosign the image after it is mirrored, e.g. `projects/my-project/locations/global/keyRings/my-ring/cryptoKeys/my-key/cryptoKeyVersions/1`. Optional.",
 				Optional:            true,
 			},
+			"artifact": schema.BoolAttribute{
+				MarkdownDescription: "Mirror the source as a generic OCI artifact rather than a container image. " +
+					"The manifest or index is copied by descriptor, preserving custom artifact types, config media types " +
+					"and annotations. Use this for Helm charts, WASM modules or policy bundles stored in OCI registries.",
+				Optional: true,
+			},
+			"additional_tags": schema.SetAttribute{
+				MarkdownDescription: "Additional tags pushed to the destination repository for the mirrored image, e.g. `[\"1.27\", \"stable\"]`.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"retain_previous": schema.Int64Attribute{
+				MarkdownDescription: "Number of previously mirrored digests to keep in the destination repository for rollbacks. " +
+					"When the source digest changes, the image is mirrored in place and the replaced digest is tagged `<tag>-previous-1`, " +
+					"`<tag>` being the destination tag, older ones are shifted to `<tag>-previous-<n>` and only the last `retain_previous` " +
+					"digests are kept. The history tags are removed when the resource is destroyed. Defaults to `0`, the resource is " +
+					"replaced and replaced digests are deleted.",
+				Optional: true,
+			},
+			"previous_digests": schema.ListAttribute{
+				MarkdownDescription: "Digest references of the previously mirrored images retained in the destination repository, most recent first.",
+				Computed:            true,
+				ElementType:         types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"generate_sbom": schema.BoolAttribute{
+				MarkdownDescription: "Generate an SBOM for the mirrored image and attach it as a signed attestation. The image layers are " +
+					"walked locally to catalogue OS packages (dpkg, apk and rpm databases) and language packages pinned in lockfiles " +
+					"(`package-lock.json`, `requirements.txt`, `poetry.lock`, `Cargo.lock`, `Gemfile.lock` and `go.mod`). Files that can't be " +
+					"parsed are skipped, reported as warnings and listed in the SBOM. Requires `kms_key_id`.",
+				Optional: true,
+			},
+			"sbom_format": schema.StringAttribute{
+				MarkdownDescription: "Format of the generated SBOM, either `spdx-json` or `cyclonedx-json`. Defaults to `spdx-json`.",
+				Optional:            true,
+			},
+			"sbom_digest": schema.StringAttribute{
+				MarkdownDescription: "SHA256 digest of the SBOM document attached to the mirrored image.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
 			"id": schema.StringAttribute{
 				MarkdownDescription: "Repository reference for the mirrored image in the destination, referenced by the image digest, rather than the tag.",
 				Computed:            true,
@@ -64,12 +120,93 @@ func (r *ImageSyncResource) Schema(ctx context.Context, req resource.SchemaReque
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
+			"vulnerability_findings": schema.MapAttribute{
+				MarkdownDescription: "Number of vulnerabilities found in the source image by the `vulnerability_gate`, keyed by " +
+					"severity (`critical`, `high`, `medium`, `low` and `unknown`). Allow-listed vulnerabilities are not counted.",
+				Computed:    true,
+				ElementType: types.Int64Type,
+				PlanModifiers: []planmodifier.Map{
+					planmodifiers.VulnerabilityGateModifier(),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"vulnerability_gate": schema.SingleNestedBlock{
+				MarkdownDescription: "Refuse to mirror images with known vulnerabilities. The packages of the source image are " +
+					"catalogued and matched against a vulnerability database on the local filesystem, so no scanning " +
+					"service is called. The gate is checked at plan time and again before the image is mirrored.",
+				Attributes: map[string]schema.Attribute{
+					"db_path": schema.StringAttribute{
+						MarkdownDescription: "Path to the vulnerability database in the [OSV](https://ossf.github.io/osv-schema/) format: " +
+							"a JSON file holding one or a list of OSV entries, a zip archive as exported by osv.dev " +
+							"(e.g. `Debian/all.zip`) or a directory of OSV JSON files.",
+						Required: true,
+					},
+					"severity_threshold": schema.StringAttribute{
+						MarkdownDescription: "Minimum severity of the vulnerabilities failing the gate, one of `low`, `medium`, " +
+							"`high` or `critical`. Defaults to `critical`.",
+						Optional: true,
+					},
+					"allow": schema.SetAttribute{
+						MarkdownDescription: "Vulnerability IDs, or their aliases, that never fail the gate, e.g. `[\"CVE-2024-1234\"]`.",
+						Optional:            true,
+						ElementType:         types.StringType,
+					},
+					"ignore_unfixed": schema.BoolAttribute{
+						MarkdownDescription: "Only fail the gate for vulnerabilities that have a fix available. Defaults to `true`.",
+						Optional:            true,
+					},
+				},
+			},
 		},
 		MarkdownDescription: "Resource to import and sync images from public container registries into your own" +
 			"Google Container Registries (GCR) or Google Artifact Registries (GAR).",
 	}
 }
 
+// ModifyPlan marks the computed attributes changing with the mirrored digest as
+// unknown when the source digest changes in place, and the SBOM digest when the
+// SBOM is attested again, their state being used otherwise.
+func (r *ImageSyncResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plannedDigest, stateDigest types.String
+	var plannedRetain, stateRetain types.Int64
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("source_digest"), &plannedDigest)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("source_digest"), &stateDigest)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("retain_previous"), &plannedRetain)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("retain_previous"), &stateRetain)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	digestChanged := !plannedDigest.Equal(stateDigest)
+	if digestChanged {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("id"), types.StringUnknown())...)
+	}
+	if digestChanged || !plannedRetain.Equal(stateRetain) {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("previous_digests"), types.ListUnknown(types.StringType))...)
+	}
+
+	// the SBOM is attested again by Update when any of these change
+	var plannedSbom, stateSbom types.Bool
+	var plannedFormat, stateFormat, plannedKey, stateKey types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("generate_sbom"), &plannedSbom)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("generate_sbom"), &stateSbom)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("sbom_format"), &plannedFormat)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("sbom_format"), &stateFormat)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("kms_key_id"), &plannedKey)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("kms_key_id"), &stateKey)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if digestChanged || !plannedSbom.Equal(stateSbom) || !plannedFormat.Equal(stateFormat) || !plannedKey.Equal(stateKey) {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("sbom_digest"), types.StringUnknown())...)
+	}
+}
+
 func (r *ImageSyncResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var data models.ImageSyncResourceModel
 
@@ -85,54 +222,45 @@ func (r *ImageSyncResource) Create(ctx context.Context, req resource.CreateReque
 		return
 	}
 
-	src := data.Source.ValueString()
 	dest := data.Destination.ValueString()
 
-	// getting source image
-	srcImg, exists, srcDigest, err := image.GetRemoteImage(src, authn.Anonymous)
-	switch {
-	case err != nil:
-		resp.Diagnostics.AddError("failed to get remote image", err.Error())
-		return
-	case !exists:
-		resp.Diagnostics.AddError("source image does not exist", src)
+	if image.IsLocalReference(dest) && !data.KmsKeyId.IsNull() {
+		resp.Diagnostics.AddError("signing is not supported for local destinations", "`kms_key_id` can only be used with registry destinations")
 		return
 	}
-
-	destRef, err := name.ParseReference(dest, name.WeakValidation)
-	if err != nil {
-		resp.Diagnostics.AddError("failed to parse destination reference", err.Error())
+	if image.IsLocalReference(dest) && (len(data.AdditionalTags.Elements()) > 0 || data.RetainPrevious.ValueInt64() > 0) {
+		resp.Diagnostics.AddError("tags are not supported for local destinations", "`additional_tags` and `retain_previous` can only be used with registry destinations")
 		return
 	}
-
-	if err := remote.Write(destRef, srcImg, remote.WithAuth(googleAuth)); err != nil {
-		resp.Diagnostics.AddError("failed to write image", err.Error())
+	if data.RetainPrevious.ValueInt64() < 0 {
+		resp.Diagnostics.AddError("invalid retain_previous", "`retain_previous` must be greater than or equal to 0")
 		return
 	}
-
-	// get the image from registry to verify it was properly written
-	destImg, exists, destDigest, err := image.GetRemoteImage(dest, googleAuth)
-	switch {
-	case err != nil:
-		resp.Diagnostics.AddError("failed to get registry image", err.Error())
+	if _, err := name.NewTag(dest, name.WeakValidation); data.RetainPrevious.ValueInt64() > 0 && err != nil {
+		resp.Diagnostics.AddError("invalid retain_previous", "`retain_previous` requires a tagged destination, the history of the replaced digests being kept per tag")
 		return
-	case !exists:
-		resp.Diagnostics.AddError("image did not get synched properly", dest)
+	}
+	resp.Diagnostics.Append(validateSBOM(&data)...)
+	if resp.Diagnostics.HasError() {
 		return
-	case srcDigest != destDigest:
-		resp.Diagnostics.AddError("image did not get synched properly", fmt.Sprintf("source and destination digests do not match: %s != %s", srcDigest, destDigest))
 	}
 
-	imgID, err := image.ImageID(data.Destination.ValueString(), destImg)
-	if err != nil {
-		resp.Diagnostics.AddError("failed to get image ID", err.Error())
+	if data.VulnerabilityGate != nil {
+		resp.Diagnostics.Append(checkVulnerabilities(ctx, &data, resp.Private)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	} else {
+		data.VulnerabilityFindings = types.MapNull(types.Int64Type)
+	}
+
+	resp.Diagnostics.Append(mirror(&data, googleAuth)...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
-	data.Id = types.StringValue(imgID)
-	data.SourceDigest = types.StringValue(srcDigest)
 
 	if !data.KmsKeyId.IsNull() && !data.KmsKeyId.IsUnknown() {
-		digestRef, err := name.NewDigest(imgID)
+		digestRef, err := name.NewDigest(data.Id.ValueString())
 		if err != nil {
 			resp.Diagnostics.AddError("failed to parse digest reference for signing", err.Error())
 			return
@@ -143,6 +271,19 @@ func (r *ImageSyncResource) Create(ctx context.Context, req resource.CreateReque
 		}
 	}
 
+	data.SbomDigest = types.StringNull()
+	if data.GenerateSbom.ValueBool() {
+		resp.Diagnostics.Append(attestSBOM(ctx, &data, googleAuth)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(syncTags(ctx, &data, nil, googleAuth)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
@@ -163,22 +304,46 @@ func (r *ImageSyncResource) Read(ctx context.Context, req resource.ReadRequest,
 
 	dest := data.Destination.ValueString()
 
-	destImg, exists, _, err := image.GetRemoteImage(dest, googleAuth)
-	switch {
-	case err != nil:
-		resp.Diagnostics.AddError("failed to get destination image", err.Error())
-		return
-	case !exists:
-		resp.State.RemoveResource(ctx)
-		return
+	var imgID string
+	if data.Artifact.ValueBool() {
+		destDesc, exists, _, err := image.GetRemoteDescriptor(dest, googleAuth)
+		switch {
+		case err != nil:
+			resp.Diagnostics.AddError("failed to get destination artifact", err.Error())
+			return
+		case !exists:
+			resp.State.RemoveResource(ctx)
+			return
+		}
+
+		imgID, err = image.ArtifactID(dest, destDesc)
+		if err != nil {
+			resp.Diagnostics.AddError("failed to get artifact ID", err.Error())
+			return
+		}
+	} else {
+		destImg, exists, _, err := image.GetImage(dest, googleAuth)
+		switch {
+		case err != nil:
+			resp.Diagnostics.AddError("failed to get destination image", err.Error())
+			return
+		case !exists:
+			resp.State.RemoveResource(ctx)
+			return
+		}
+
+		imgID, err = image.ImageID(dest, destImg)
+		if err != nil {
+			resp.Diagnostics.AddError("failed to get image ID", err.Error())
+			return
+		}
 	}
+	data.Id = types.StringValue(imgID)
 
-	imgID, err := image.ImageID(data.Destination.ValueString(), destImg)
-	if err != nil {
-		resp.Diagnostics.AddError("failed to get image ID", err.Error())
+	resp.Diagnostics.Append(refreshPreviousDigests(ctx, &data, googleAuth)...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
-	data.Id = types.StringValue(imgID)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -194,17 +359,100 @@ func (r *ImageSyncResource) Update(ctx context.Context, req resource.UpdateReque
 		return
 	}
 
-	// Updates are triggered by source tag changes (same digest, different tag)
-	// or by adding/changing kms_key_id. No image copy is necessary; just
-	// propagate config changes to state and re-sign if needed.
+	// Updates are triggered by source tag changes (same digest, different tag),
+	// by adding/changing kms_key_id or by changing the destination tags. No image
+	// copy is necessary; just propagate config changes to state, re-sign and
+	// re-tag if needed. With retain_previous, source digest changes are updates
+	// too: the new digest is mirrored and the replaced one kept in the history
+	// of the destination tag.
 	state.Source = config.Source
+	state.Artifact = config.Artifact
+
+	if image.IsLocalReference(state.Destination.ValueString()) && (len(config.AdditionalTags.Elements()) > 0 || config.RetainPrevious.ValueInt64() > 0) {
+		resp.Diagnostics.AddError("tags are not supported for local destinations", "`additional_tags` and `retain_previous` can only be used with registry destinations")
+		return
+	}
+	if config.RetainPrevious.ValueInt64() < 0 {
+		resp.Diagnostics.AddError("invalid retain_previous", "`retain_previous` must be greater than or equal to 0")
+		return
+	}
+	if _, err := name.NewTag(state.Destination.ValueString(), name.WeakValidation); config.RetainPrevious.ValueInt64() > 0 && err != nil {
+		resp.Diagnostics.AddError("invalid retain_previous", "`retain_previous` requires a tagged destination, the history of the replaced digests being kept per tag")
+		return
+	}
+
+	// Capture the old SBOM settings before overwriting, so a new SBOM is only
+	// generated when they change.
+	oldGenerateSbom, oldSbomFormat := state.GenerateSbom, state.SbomFormat
+	state.GenerateSbom = config.GenerateSbom
+	state.SbomFormat = config.SbomFormat
+
+	// Capture the old tags before overwriting, so removed tags can be deleted.
+	oldTags := state.AdditionalTags
+	state.AdditionalTags = config.AdditionalTags
+	state.RetainPrevious = config.RetainPrevious
 
 	// Capture the old key before overwriting, so the comparison below is valid.
 	oldKmsKeyId := state.KmsKeyId
 	state.KmsKeyId = config.KmsKeyId
 
-	// Re-sign if the KMS key was added or changed.
-	if !config.KmsKeyId.IsNull() && !config.KmsKeyId.IsUnknown() && !config.KmsKeyId.Equal(oldKmsKeyId) {
+	// The vulnerability gate was already checked against the source image by the
+	// plan modifier, keep the planned findings.
+	state.VulnerabilityGate = config.VulnerabilityGate
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("vulnerability_findings"), &state.VulnerabilityFindings)...)
+	var plannedDigest types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("source_digest"), &plannedDigest)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	digestChanged := !plannedDigest.IsUnknown() && !plannedDigest.Equal(state.SourceDigest)
+	if digestChanged {
+		googleAuth, err := google.NewEnvAuthenticator(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError("failed to create google authenticator", err.Error())
+			return
+		}
+
+		if state.VulnerabilityGate != nil {
+			resp.Diagnostics.Append(checkVulnerabilities(ctx, &state, resp.Private)...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+		}
+
+		replaced, err := name.NewDigest(state.Id.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("failed to parse digest reference", err.Error())
+			return
+		}
+		destTag, err := name.NewTag(state.Destination.ValueString(), name.WeakValidation)
+		if err != nil {
+			resp.Diagnostics.AddError("failed to parse destination tag", err.Error())
+			return
+		}
+
+		// the replaced digest is tagged before the destination tag moves, so that
+		// it is never left untagged, e.g. for the garbage collector of the registry
+		if err := image.RetainPrevious(replaced, destTag, int(state.RetainPrevious.ValueInt64()), googleAuth); err != nil {
+			resp.Diagnostics.AddError("failed to retain previous digest", err.Error())
+			return
+		}
+
+		resp.Diagnostics.Append(mirror(&state, googleAuth)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	// Re-sign if the KMS key was added or changed, or if a new digest was
+	// mirrored.
+	if !config.KmsKeyId.IsNull() && !config.KmsKeyId.IsUnknown() && (!config.KmsKeyId.Equal(oldKmsKeyId) || digestChanged) {
+		if image.IsLocalReference(state.Destination.ValueString()) {
+			resp.Diagnostics.AddError("signing is not supported for local destinations", "`kms_key_id` can only be used with registry destinations")
+			return
+		}
+
 		googleAuth, err := google.NewEnvAuthenticator(ctx)
 		if err != nil {
 			resp.Diagnostics.AddError("failed to create google authenticator", err.Error())
@@ -221,6 +469,41 @@ func (r *ImageSyncResource) Update(ctx context.Context, req resource.UpdateReque
 		}
 	}
 
+	// Re-attest the SBOM if it was enabled, its format changed or the image was
+	// signed with a new key or mirrored again.
+	sbomChanged := !config.GenerateSbom.Equal(oldGenerateSbom) || !config.SbomFormat.Equal(oldSbomFormat) || !config.KmsKeyId.Equal(oldKmsKeyId) || digestChanged
+	if !config.GenerateSbom.ValueBool() {
+		state.SbomDigest = types.StringNull()
+	} else if sbomChanged {
+		resp.Diagnostics.Append(validateSBOM(&state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		googleAuth, err := google.NewEnvAuthenticator(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError("failed to create google authenticator", err.Error())
+			return
+		}
+		resp.Diagnostics.Append(attestSBOM(ctx, &state, googleAuth)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if !image.IsLocalReference(state.Destination.ValueString()) {
+		googleAuth, err := google.NewEnvAuthenticator(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError("failed to create google authenticator", err.Error())
+			return
+		}
+
+		resp.Diagnostics.Append(syncTags(ctx, &state, &oldTags, googleAuth)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
 }
 
@@ -234,6 +517,15 @@ func (r *ImageSyncResource) Delete(ctx context.Context, req resource.DeleteReque
 	}
 
 	dest := data.Destination.ValueString()
+
+	// exported images are only removed from the layout index
+	if image.IsLocalReference(dest) {
+		if err := image.DeleteLayoutImage(dest, image.DigestFromReference(data.Id.ValueString())); err != nil {
+			resp.Diagnostics.AddError("failed to delete image from layout", err.Error())
+		}
+		return
+	}
+
 	destRef, err := name.ParseReference(dest, name.WeakValidation)
 	if err != nil {
 		resp.Diagnostics.AddError("failed to parse destination reference", err.Error())
@@ -247,6 +539,25 @@ func (r *ImageSyncResource) Delete(ctx context.Context, req resource.DeleteReque
 	}
 	authOpt := remote.WithAuth(googleAuth)
 
+	var additionalTags []string
+	resp.Diagnostics.Append(data.AdditionalTags.ElementsAs(ctx, &additionalTags, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := image.UntagImage(destRef.Context(), additionalTags, googleAuth); err != nil {
+		resp.Diagnostics.AddError("failed to delete additional tags", err.Error())
+		return
+	}
+
+	// the history of the destination tag is only kept while the resource
+	// exists, destroying it removes the history tags
+	if destTag, ok := destRef.(name.Tag); ok && data.RetainPrevious.ValueInt64() > 0 {
+		if err := image.PrunePrevious(destTag, 0, googleAuth); err != nil {
+			resp.Diagnostics.AddError("failed to delete previous digests", err.Error())
+			return
+		}
+	}
+
 	// delete this tag. Perform this regardless of if other tags exist
 	if err := remote.Delete(destRef, authOpt); err != nil {
 		resp.Diagnostics.AddError("failed to delete image", err.Error())
@@ -272,23 +583,17 @@ func (r *ImageSyncResource) Delete(ctx context.Context, req resource.DeleteReque
 			return
 		}
 
-		i, err := remote.Image(imgRef, authOpt)
+		imageID, err := tagDigest(imgRef, data.Artifact.ValueBool(), authOpt)
 		if err != nil {
 			if strings.Contains(err.Error(), "MANIFEST_UNKNOWN") {
 				// this image layer can't be found, it must have been deleted already!
 				continue
 			}
-			resp.Diagnostics.AddError("failed to get image", err.Error())
-			return
-		}
-
-		imageID, err := i.Digest()
-		if err != nil {
 			resp.Diagnostics.AddError("failed to get image digest", err.Error())
 			return
 		}
 
-		if imageID.String() == image.DigestFromReference(data.Id.ValueString()) {
+		if imageID == image.DigestFromReference(data.Id.ValueString()) {
 			// another image is using the same layers as we are, do not delete these
 			// layers!
 			return
@@ -305,6 +610,330 @@ func (r *ImageSyncResource) Delete(ctx context.Context, req resource.DeleteReque
 	remote.Delete(idRef, authOpt)
 }
 
+// mirror copies the source of the resource to its destination, as an image or
+// as an artifact, and sets the ID and source digest of the resource.
+func mirror(data *models.ImageSyncResourceModel, auth authn.Authenticator) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	src := data.Source.ValueString()
+	dest := data.Destination.ValueString()
+
+	var imgID, srcDigest string
+	if data.Artifact.ValueBool() {
+		if image.IsLocalReference(src) || image.IsLocalReference(dest) {
+			diags.AddError("artifacts are not supported for local references", "`artifact` can only be used with registry sources and destinations")
+			return diags
+		}
+
+		destRef, err := name.ParseReference(dest, name.WeakValidation)
+		if err != nil {
+			diags.AddError("failed to parse destination reference", err.Error())
+			return diags
+		}
+		imgID, srcDigest = syncArtifact(src, dest, destRef, auth, &diags)
+	} else {
+		imgID, srcDigest = syncImage(src, dest, auth, &diags)
+	}
+	if diags.HasError() {
+		return diags
+	}
+
+	data.Id = types.StringValue(imgID)
+	data.SourceDigest = types.StringValue(srcDigest)
+	return diags
+}
+
+// syncImage copies the source container image to the destination and verifies
+// that the digests match. It returns the destination image ID and the digest of
+// the source image. Both the source and destination can be local references.
+func syncImage(src, dest string, auth authn.Authenticator, diags *diag.Diagnostics) (string, string) {
+	srcImg, exists, srcDigest, err := image.GetImage(src, authn.Anonymous)
+	switch {
+	case err != nil:
+		diags.AddError("failed to get remote image", err.Error())
+		return "", ""
+	case !exists:
+		diags.AddError("source image does not exist", src)
+		return "", ""
+	}
+
+	if err := image.WriteImage(dest, srcImg, auth); err != nil {
+		diags.AddError("failed to write image", err.Error())
+		return "", ""
+	}
+
+	// get the image from registry to verify it was properly written
+	destImg, exists, destDigest, err := image.GetImage(dest, auth)
+	switch {
+	case err != nil:
+		diags.AddError("failed to get registry image", err.Error())
+		return "", ""
+	case !exists:
+		diags.AddError("image did not get synched properly", dest)
+		return "", ""
+	case srcDigest != destDigest:
+		diags.AddError("image did not get synched properly", fmt.Sprintf("source and destination digests do not match: %s != %s", srcDigest, destDigest))
+	}
+
+	imgID, err := image.ImageID(dest, destImg)
+	if err != nil {
+		diags.AddError("failed to get image ID", err.Error())
+		return "", ""
+	}
+
+	return imgID, srcDigest
+}
+
+// syncArtifact copies the source manifest or index to the destination by
+// descriptor and verifies that the digests match. It returns the destination
+// artifact ID and the digest of the source artifact.
+func syncArtifact(src, dest string, destRef name.Reference, auth authn.Authenticator, diags *diag.Diagnostics) (string, string) {
+	srcDesc, exists, srcDigest, err := image.GetRemoteDescriptor(src, authn.Anonymous)
+	switch {
+	case err != nil:
+		diags.AddError("failed to get remote artifact", err.Error())
+		return "", ""
+	case !exists:
+		diags.AddError("source artifact does not exist", src)
+		return "", ""
+	}
+
+	if err := image.CopyArtifact(srcDesc, destRef, auth); err != nil {
+		diags.AddError("failed to write artifact", err.Error())
+		return "", ""
+	}
+
+	destDesc, exists, destDigest, err := image.GetRemoteDescriptor(dest, auth)
+	switch {
+	case err != nil:
+		diags.AddError("failed to get registry artifact", err.Error())
+		return "", ""
+	case !exists:
+		diags.AddError("artifact did not get synched properly", dest)
+		return "", ""
+	case srcDigest != destDigest:
+		diags.AddError("artifact did not get synched properly", fmt.Sprintf("source and destination digests do not match: %s != %s", srcDigest, destDigest))
+	}
+
+	artifactID, err := image.ArtifactID(dest, destDesc)
+	if err != nil {
+		diags.AddError("failed to get artifact ID", err.Error())
+		return "", ""
+	}
+
+	return artifactID, srcDigest
+}
+
+// checkVulnerabilities checks the source image against the vulnerability gate
+// before it is mirrored, in case it changed since the plan.
+func checkVulnerabilities(ctx context.Context, data *models.ImageSyncResourceModel, private planmodifiers.PrivateState) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if data.Artifact.ValueBool() {
+		diags.AddError("vulnerability gate is not supported for artifacts", "`vulnerability_gate` can only be used with container images")
+		return diags
+	}
+
+	src := data.Source.ValueString()
+	img, exists, _, err := image.GetImage(src, authn.Anonymous)
+	switch {
+	case err != nil:
+		diags.AddError("failed to get remote image", err.Error())
+		return diags
+	case !exists:
+		diags.AddError("source image does not exist", src)
+		return diags
+	}
+
+	findings, d := planmodifiers.CheckVulnerabilities(ctx, img, data.VulnerabilityGate, private)
+	diags.Append(d...)
+	data.VulnerabilityFindings = findings
+	return diags
+}
+
+// validateSBOM checks that an SBOM can be generated for the resource.
+func validateSBOM(data *models.ImageSyncResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if !data.GenerateSbom.ValueBool() {
+		return diags
+	}
+
+	switch {
+	case data.KmsKeyId.IsNull():
+		diags.AddError("missing kms_key_id", "`generate_sbom` requires `kms_key_id` to sign the SBOM attestation")
+	case data.Artifact.ValueBool():
+		diags.AddError("SBOMs are not supported for artifacts", "`generate_sbom` can only be used with container images")
+	case image.IsLocalReference(data.Destination.ValueString()):
+		diags.AddError("SBOMs are not supported for local destinations", "`generate_sbom` can only be used with registry destinations")
+	}
+
+	switch sbom.Format(data.SbomFormat.ValueString()) {
+	case "", sbom.SPDXJSON, sbom.CycloneDXJSON:
+	default:
+		diags.AddError("invalid sbom_format", fmt.Sprintf("`sbom_format` must be either %q or %q", sbom.SPDXJSON, sbom.CycloneDXJSON))
+	}
+	return diags
+}
+
+// attestSBOM catalogues the packages of the mirrored image, renders the SBOM
+// and attaches it to the image as an attestation signed with the KMS key.
+func attestSBOM(ctx context.Context, data *models.ImageSyncResourceModel, auth authn.Authenticator) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	digestRef, err := name.NewDigest(data.Id.ValueString())
+	if err != nil {
+		diags.AddError("failed to parse digest reference for SBOM", err.Error())
+		return diags
+	}
+
+	img, exists, _, err := image.GetRemoteImage(digestRef.String(), auth)
+	switch {
+	case err != nil:
+		diags.AddError("failed to get registry image", err.Error())
+		return diags
+	case !exists:
+		diags.AddError("image does not exist", digestRef.String())
+		return diags
+	}
+
+	catalog, err := sbom.CatalogImage(img)
+	if err != nil {
+		diags.AddError("failed to catalogue image packages", err.Error())
+		return diags
+	}
+	for _, f := range catalog.Skipped {
+		diags.AddWarning("file skipped from the SBOM", f.String())
+	}
+
+	var created time.Time
+	if cfg, err := img.ConfigFile(); err == nil {
+		created = cfg.Created.Time
+	}
+
+	format := sbom.Format(data.SbomFormat.ValueString())
+	if format == "" {
+		format = sbom.SPDXJSON
+	}
+	subject := sbom.Subject{Name: digestRef.Context().String(), Digest: digestRef.DigestStr(), Created: created}
+	doc, err := sbom.Render(catalog, subject, format)
+	if err != nil {
+		diags.AddError("failed to render SBOM", err.Error())
+		return diags
+	}
+
+	if err := image.AttestImage(ctx, digestRef, data.KmsKeyId.ValueString(), format.PredicateType(), doc, auth); err != nil {
+		diags.AddError("failed to attest SBOM", err.Error())
+		return diags
+	}
+
+	data.SbomDigest = types.StringValue(fmt.Sprintf("sha256:%x", sha256.Sum256(doc)))
+	return diags
+}
+
+// syncTags pushes the additional tags of the resource to the destination and
+// refreshes the digest history. Tags present in oldTags but no longer configured
+// are deleted.
+func syncTags(ctx context.Context, data *models.ImageSyncResourceModel, oldTags *types.Set, auth authn.Authenticator) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if image.IsLocalReference(data.Destination.ValueString()) {
+		data.PreviousDigests = types.ListValueMust(types.StringType, []attr.Value{})
+		return diags
+	}
+
+	var tags, previousTags []string
+	diags.Append(data.AdditionalTags.ElementsAs(ctx, &tags, false)...)
+	if oldTags != nil {
+		diags.Append(oldTags.ElementsAs(ctx, &previousTags, false)...)
+	}
+	if diags.HasError() {
+		return diags
+	}
+
+	digestRef, err := name.NewDigest(data.Id.ValueString())
+	if err != nil {
+		diags.AddError("failed to parse digest reference for tagging", err.Error())
+		return diags
+	}
+
+	var removed []string
+	for _, tag := range previousTags {
+		if !slices.Contains(tags, tag) {
+			removed = append(removed, tag)
+		}
+	}
+	if err := image.UntagImage(digestRef.Context(), removed, auth); err != nil {
+		diags.AddError("failed to delete additional tags", err.Error())
+		return diags
+	}
+
+	if err := image.TagImage(digestRef, tags, auth); err != nil {
+		diags.AddError("failed to push additional tags", err.Error())
+		return diags
+	}
+
+	if destTag, err := name.NewTag(data.Destination.ValueString(), name.WeakValidation); err == nil {
+		if err := image.PrunePrevious(destTag, int(data.RetainPrevious.ValueInt64()), auth); err != nil {
+			diags.AddError("failed to prune previous digests", err.Error())
+			return diags
+		}
+	}
+
+	diags.Append(refreshPreviousDigests(ctx, data, auth)...)
+	return diags
+}
+
+// refreshPreviousDigests reads the digest history of the destination repository.
+func refreshPreviousDigests(ctx context.Context, data *models.ImageSyncResourceModel, auth authn.Authenticator) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	retain := int(data.RetainPrevious.ValueInt64())
+	if retain <= 0 || image.IsLocalReference(data.Destination.ValueString()) {
+		data.PreviousDigests = types.ListValueMust(types.StringType, []attr.Value{})
+		return diags
+	}
+
+	destTag, err := name.NewTag(data.Destination.ValueString(), name.WeakValidation)
+	if err != nil {
+		diags.AddError("failed to parse destination tag", err.Error())
+		return diags
+	}
+
+	digests, err := image.PreviousDigests(destTag, retain, auth)
+	if err != nil {
+		diags.AddError("failed to list previous digests", err.Error())
+		return diags
+	}
+
+	var newDiags diag.Diagnostics
+	data.PreviousDigests, newDiags = types.ListValueFrom(ctx, types.StringType, digests)
+	diags.Append(newDiags...)
+	return diags
+}
+
+// tagDigest returns the digest referenced by a tag. Artifacts are resolved by
+// their descriptor as they might not be images.
+func tagDigest(ref name.Reference, artifact bool, authOpt remote.Option) (string, error) {
+	if artifact {
+		desc, err := remote.Head(ref, authOpt)
+		if err != nil {
+			return "", err
+		}
+		return desc.Digest.String(), nil
+	}
+
+	i, err := remote.Image(ref, authOpt)
+	if err != nil {
+		return "", err
+	}
+
+	imageID, err := i.Digest()
+	if err != nil {
+		return "", err
+	}
+	return imageID.String(), nil
+}
+
 func (r *ImageSyncResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
 	imageParts := strings.Split(req.ID, ",")
 
@@ -1,15 +1,25 @@
 package provider
 
 import (
+	"archive/tar"
+	"bytes"
 	"fmt"
+	"io"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
 	"testing"
 
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/registry" // Modified to allow registry deletes
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/plancheck"
@@ -99,6 +109,194 @@ func TestImageSyncBasic(t *testing.T) {
 	})
 }
 
+func TestImageSyncArtifact(t *testing.T) {
+	srcReg := httptest.NewServer(registry.New())
+	defer srcReg.Close()
+
+	destReg := httptest.NewServer(registry.New())
+	defer destReg.Close()
+
+	// an index is not resolved to a platform image when mirrored as an artifact
+	fakeIdx, _ := random.Index(10, 1, 2)
+	fakeIdxDigest, _ := fakeIdx.Digest()
+
+	ref, err := name.ParseReference(srcReg.URL[7:]+"/charts/bundle:1.0", name.WeakValidation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.WriteIndex(ref, fakeIdx); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:               true,
+		PreCheck:                 nil,
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             nil,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`resource "ravelin_imagesync" "artifact_unit_test" {
+					source      = "%s/charts/bundle:1.0"
+					destination = "%s/bundle:1.0"
+					artifact    = true
+				}`, srcReg.URL[7:], destReg.URL[7:]),
+				ResourceName: "ravelin_imagesync.artifact_unit_test",
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ravelin_imagesync.artifact_unit_test", "id", destReg.URL[7:]+"/bundle@"+fakeIdxDigest.String()),
+					resource.TestCheckResourceAttr("ravelin_imagesync.artifact_unit_test", "source_digest", fakeIdxDigest.String()),
+				),
+			},
+		},
+	})
+}
+
+func TestImageSyncLocalSource(t *testing.T) {
+	destReg := httptest.NewServer(registry.New())
+	defer destReg.Close()
+
+	fakeImg, _ := random.Image(10, 1)
+	fakeImgDigest, _ := fakeImg.Digest()
+
+	layoutPath := filepath.Join(t.TempDir(), "busybox")
+	if _, err := layout.Write(layoutPath, empty.Index); err != nil {
+		t.Fatal(err)
+	}
+	p, _ := layout.FromPath(layoutPath)
+	if err := p.AppendImage(fakeImg); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:               true,
+		PreCheck:                 nil,
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             nil,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`resource "ravelin_imagesync" "layout_unit_test" {
+					source      = "oci-layout://%s@%s"
+					destination = "%s/busybox:1.0"
+				}`, layoutPath, fakeImgDigest, destReg.URL[7:]),
+				ResourceName: "ravelin_imagesync.layout_unit_test",
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ravelin_imagesync.layout_unit_test", "id", destReg.URL[7:]+"/busybox@"+fakeImgDigest.String()),
+					resource.TestCheckResourceAttr("ravelin_imagesync.layout_unit_test", "source_digest", fakeImgDigest.String()),
+				),
+			},
+		},
+	})
+}
+
+func TestImageSyncTagsAndHistory(t *testing.T) {
+	srcReg := httptest.NewServer(registry.New())
+	defer srcReg.Close()
+
+	destReg := httptest.NewServer(registry.New())
+	defer destReg.Close()
+
+	fakeImg, _ := random.Image(10, 1)
+	fakeImgDigest, _ := fakeImg.Digest()
+
+	fakeImgModified, _ := random.Image(10, 1)
+	fakeImgDigestModified, _ := fakeImgModified.Digest()
+
+	initSrcImage(srcReg, "library/nginx:1.27", fakeImg)
+
+	config := fmt.Sprintf(`resource "ravelin_imagesync" "tags_unit_test" {
+		source          = "%s/library/nginx:1.27"
+		destination     = "%s/nginx:1.27.3"
+		additional_tags = ["1.27", "stable"]
+		retain_previous = 2
+	}`, srcReg.URL[7:], destReg.URL[7:])
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:               true,
+		PreCheck:                 nil,
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             nil,
+		Steps: []resource.TestStep{
+			{
+				Config:       config,
+				ResourceName: "ravelin_imagesync.tags_unit_test",
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ravelin_imagesync.tags_unit_test", "id", destReg.URL[7:]+"/nginx@"+fakeImgDigest.String()),
+					resource.TestCheckResourceAttr("ravelin_imagesync.tags_unit_test", "previous_digests.#", "0"),
+				),
+			},
+			{
+				// the image is mirrored in place and the replaced digest is kept as
+				// 1.27.3-previous-1
+				PreConfig: func() {
+					initSrcImage(srcReg, "library/nginx:1.27", fakeImgModified)
+				},
+				Config:       config,
+				ResourceName: "ravelin_imagesync.tags_unit_test",
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ravelin_imagesync.tags_unit_test", "id", destReg.URL[7:]+"/nginx@"+fakeImgDigestModified.String()),
+					resource.TestCheckResourceAttr("ravelin_imagesync.tags_unit_test", "previous_digests.#", "1"),
+					resource.TestCheckResourceAttr("ravelin_imagesync.tags_unit_test", "previous_digests.0", destReg.URL[7:]+"/nginx@"+fakeImgDigest.String()),
+				),
+			},
+		},
+	})
+}
+
+func TestImageSyncVulnerabilityGate(t *testing.T) {
+	srcReg := httptest.NewServer(registry.New())
+	defer srcReg.Close()
+
+	destReg := httptest.NewServer(registry.New())
+	defer destReg.Close()
+
+	initSrcImage(srcReg, "debian:12", debianImage(t))
+
+	dbPath := filepath.Join(t.TempDir(), "osv.json")
+	db := `[{
+		"id": "CVE-2024-0001",
+		"affected": [{
+			"package": {"ecosystem": "Debian:12", "name": "glibc"},
+			"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "2.36-9+deb12u5"}]}]
+		}],
+		"database_specific": {"severity": "CRITICAL"}
+	}]`
+	if err := os.WriteFile(dbPath, []byte(db), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := func(allow string) string {
+		return fmt.Sprintf(`resource "ravelin_imagesync" "gate_unit_test" {
+			source      = "%s/debian:12"
+			destination = "%s/debian:12"
+
+			vulnerability_gate {
+				db_path = "%s"
+				allow   = [%s]
+			}
+		}`, srcReg.URL[7:], destReg.URL[7:], dbPath, allow)
+	}
+
+	resource.Test(t, resource.TestCase{
+		IsUnitTest:               true,
+		PreCheck:                 nil,
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             nil,
+		Steps: []resource.TestStep{
+			{
+				Config:      config(""),
+				ExpectError: regexp.MustCompile("image failed the vulnerability gate"),
+			},
+			{
+				Config:       config(`"CVE-2024-0001"`),
+				ResourceName: "ravelin_imagesync.gate_unit_test",
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ravelin_imagesync.gate_unit_test", "vulnerability_findings.critical", "0"),
+					resource.TestCheckResourceAttr("ravelin_imagesync.gate_unit_test", "vulnerability_findings.%", "5"),
+				),
+			},
+		},
+	})
+}
+
 func TestImageSyncPublicImages(t *testing.T) {
 
 	destReg := httptest.NewServer(registry.New())
@@ -167,3 +365,38 @@ func initSrcImage(fakeReg *httptest.Server, path string, img v1.Image) {
 		panic(err)
 	}
 }
+
+// debianImage returns an image with a dpkg database listing a vulnerable glibc.
+func debianImage(t *testing.T) v1.Image {
+	files := map[string]string{
+		"etc/os-release":      "ID=debian\nVERSION_ID=\"12\"\n",
+		"var/lib/dpkg/status": "Package: libc6\nSource: glibc\nStatus: install ok installed\nVersion: 2.36-9+deb12u4\n",
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
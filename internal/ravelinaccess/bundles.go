@@ -0,0 +1,222 @@
+package ravelinaccess
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// bundlePrefix prefixes the references to role bundles in role lists, e.g.
+// `bundle:data-oncall`.
+const bundlePrefix = "bundle:"
+
+// roleBundle is a named set of roles defined in bundles/<name>.yml, which can be
+// referenced instead of listing its roles in escalations and project roles.
+type roleBundle struct {
+	// Description describes the bundle.
+	Description string `yaml:"description,omitempty"`
+	// Roles are granted on every project the bundle is used on. Custom roles in
+	// their short form are expanded on the project, and other bundles can be
+	// referenced.
+	Roles []string `yaml:"roles,omitempty"`
+	// Projects maps project names to the roles granted in addition to Roles
+	// when the bundle is used on the project.
+	Projects map[string][]string `yaml:"projects,omitempty"`
+
+	// file is the path of the bundle file.
+	file string
+}
+
+// roleBundles are the bundles of an IAM directory by name.
+type roleBundles map[string]*roleBundle
+
+// loadBundles reads the bundles of the bundles directory of the IAM directory,
+// their project keys selecting projects of the inventory. The bundles directory
+// is optional. The problems of every invalid bundle are
+// joined in the returned error, bundles referencing unknown bundles or each
+// other in a cycle being invalid. The names of the bundles are still returned
+// along with the error, so that the references to them can be checked.
+func loadBundles(iamDirectory string, projects projectInventory) (roleBundles, error) {
+	root := filepath.Join(iamDirectory, "bundles")
+	if _, err := os.Stat(root); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	files, err := getEntityFiles(iamDirectory, "bundles")
+	if err != nil {
+		return nil, err
+	}
+
+	// every bundle is known before the bundles are parsed, so that references
+	// to other bundles can be checked
+	var errs []error
+	bundles := make(roleBundles, len(files))
+	for _, f := range files {
+		file := filepath.Join(root, f)
+		name := strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
+		if other, ok := bundles[name]; ok {
+			errs = append(errs, fmt.Errorf("duplicate bundle %s in %s and %s", name, other.file, file))
+			continue
+		}
+		bundles[name] = &roleBundle{file: file}
+	}
+
+	for _, bundle := range bundles {
+		data, err := readFile(bundle.file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := bundles.decode(bundle, data, projects); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		bundle.Projects = projects.expandProjects(bundle.Projects)
+	}
+	if len(errs) == 0 {
+		if err := bundles.checkCycles(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return bundles.names(), err
+	}
+	return bundles, nil
+}
+
+// names returns bundles with the names of the bundles only, which expand to no
+// role, even if the bundles are invalid or reference each other in a cycle.
+func (b roleBundles) names() roleBundles {
+	names := make(roleBundles, len(b))
+	for name, bundle := range b {
+		names[name] = &roleBundle{file: bundle.file}
+	}
+	return names
+}
+
+// decode decodes the bundle file into the bundle, reporting unknown fields,
+// invalid roles and unknown bundles with their position.
+func (b roleBundles) decode(bundle *roleBundle, data []byte, projects projectInventory) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return &ValidationError{File: bundle.file, Msg: err.Error()}
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	v := &validator{file: bundle.file, bundles: b, projects: projects}
+	root := doc.Content[0]
+	v.checkNode(root, reflect.TypeOf(roleBundle{}), "")
+	if roles := lookup(root, "roles"); roles != nil && roles.Kind == yaml.SequenceNode {
+		for _, role := range roles.Content {
+			v.checkRole(role, "")
+		}
+	}
+	v.checkRoles(lookup(root, "projects"), "bundle projects")
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	if err := doc.Decode(bundle); err != nil {
+		return &ValidationError{File: bundle.file, Msg: err.Error()}
+	}
+	return nil
+}
+
+// references returns the names of the bundles the bundle references, on any
+// project.
+func (r *roleBundle) references() []string {
+	var names []string
+	roles := slices.Clone(r.Roles)
+	for _, projectRoles := range r.Projects {
+		roles = append(roles, projectRoles...)
+	}
+	for _, role := range roles {
+		if name, ok := strings.CutPrefix(role, bundlePrefix); ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// checkCycles returns an error if bundles reference each other in a cycle.
+func (b roleBundles) checkCycles() error {
+	done := make(map[string]bool, len(b))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		if i := slices.Index(path, name); i != -1 {
+			return &ValidationError{File: b[name].file, Msg: fmt.Sprintf("bundle cycle detected: %s", strings.Join(append(path[i:], name), " -> "))}
+		}
+		if done[name] {
+			return nil
+		}
+		for _, ref := range b[name].references() {
+			if err := visit(ref, append(path, name)); err != nil {
+				return err
+			}
+		}
+		done[name] = true
+		return nil
+	}
+
+	names := make([]string, 0, len(b))
+	for name := range b {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandBundles replaces the references to bundles of the map of projects to
+// roles by the roles of the bundles on each project, nested bundles included.
+// Roles granted several times are only kept once.
+func (b roleBundles) expandBundles(m map[string][]string) map[string][]string {
+	for project, roles := range m {
+		if slices.ContainsFunc(roles, isBundle) {
+			m[project] = b.expand(project, roles)
+		}
+	}
+	return m
+}
+
+// expand returns the roles with the references to bundles replaced by the roles
+// of the bundles on the project. References to unknown bundles are dropped, they
+// are reported when the file is validated.
+func (b roleBundles) expand(project string, roles []string) []string {
+	var expanded []string
+	for _, role := range roles {
+		name, ok := strings.CutPrefix(role, bundlePrefix)
+		if !ok {
+			if !slices.Contains(expanded, role) {
+				expanded = append(expanded, role)
+			}
+			continue
+		}
+		bundle, ok := b[name]
+		if !ok {
+			continue
+		}
+		for _, r := range b.expand(project, append(slices.Clone(bundle.Roles), bundle.Projects[project]...)) {
+			if !slices.Contains(expanded, r) {
+				expanded = append(expanded, r)
+			}
+		}
+	}
+	return expanded
+}
+
+func isBundle(role string) bool {
+	return strings.HasPrefix(role, bundlePrefix)
+}
@@ -0,0 +1,118 @@
+package ravelinaccess
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundles(t *testing.T) {
+	setNow(t, date("2026-11-20"))
+
+	dir := createTempFiles(t, map[string][]byte{
+		"bundles/base.yml": []byte(`description: Read access
+roles:
+  - roles/viewer
+  - custom/reader
+`),
+		"bundles/data-oncall.yaml": []byte(`description: Data engineer on-call
+roles:
+  - bundle:base
+  - roles/bigquery.admin
+  - roles/viewer
+projects:
+  prod-data:
+    - roles/bigquery.jobUser
+`),
+		"users/john_doe.yml": []byte(`gcp:
+  roles:
+    my-project:
+      - bundle:base
+gsudo:
+  escalations:
+    prod-data:
+      - bundle:data-oncall
+      - roles/owner
+    staging-data:
+      - role: bundle:data-oncall
+        expires: 2026-12-31
+`),
+	})
+
+	d, err := LoadDirectory(dir, Settings{})
+	require.NoError(t, err)
+	user, ok := d.ByEmail("john.doe@ravelin.com")
+	require.True(t, ok)
+
+	require.Equal(t, map[string][]string{
+		"my-project": {"roles/viewer", "projects/my-project/roles/reader"},
+	}, user.GCP.Roles)
+	require.Equal(t, map[string][]string{
+		"prod-data":    {"roles/viewer", "projects/prod-data/roles/reader", "roles/bigquery.admin", "roles/bigquery.jobUser", "roles/owner"},
+		"staging-data": {"roles/viewer", "projects/staging-data/roles/reader", "roles/bigquery.admin"},
+	}, user.Gsudo.Escalations)
+	require.Equal(t, map[string]map[string]time.Time{
+		"staging-data": {
+			"roles/viewer":                       date("2026-12-31"),
+			"projects/staging-data/roles/reader": date("2026-12-31"),
+			"roles/bigquery.admin":               date("2026-12-31"),
+		},
+	}, user.Gsudo.Expiries)
+
+	// files outside of a directory use the bundles of their IAM directory too
+	access, err := ExtractRavelinAccess(filepath.Join(dir, "users", "john_doe.yml"), Settings{})
+	require.NoError(t, err)
+	require.Equal(t, user.Gsudo.Escalations, access.Gsudo.Escalations)
+}
+
+func TestBundles_Invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string][]byte
+		expErrors []string
+	}{
+		{
+			name: "unknown_bundle",
+			files: map[string][]byte{
+				"users/john_doe.yml": []byte("gsudo:\n  escalations:\n    my-project:\n      - bundle:missing\n"),
+			},
+			expErrors: []string{`users/john_doe.yml:4:9: unknown bundle "missing" for project my-project`},
+		},
+		{
+			name: "invalid_bundle",
+			files: map[string][]byte{
+				"bundles/base.yml":   []byte("role:\n  - roles/viewer\nroles:\n  - viewer\n  - bundle:missing\n"),
+				"users/john_doe.yml": []byte("gsudo:\n  escalations:\n    my-project:\n      - bundle:base\n"),
+			},
+			expErrors: []string{
+				`bundles/base.yml:1:1: unknown field "role" in the file`,
+				`bundles/base.yml:4:5: invalid role "viewer"`,
+				`bundles/base.yml:5:5: unknown bundle "missing"`,
+			},
+		},
+		{
+			name: "bundle_cycle",
+			files: map[string][]byte{
+				"bundles/a.yml":      []byte("roles:\n  - bundle:b\n"),
+				"bundles/b.yml":      []byte("projects:\n  my-project:\n    - bundle:a\n"),
+				"users/john_doe.yml": []byte("gsudo:\n  escalations:\n    my-project:\n      - bundle:a\n"),
+			},
+			expErrors: []string{"bundle cycle detected: a -> b -> a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := createTempFiles(t, tt.files)
+
+			_, err := LoadDirectory(dir, Settings{})
+			errs := Errors(err)
+			require.Len(t, errs, len(tt.expErrors))
+			for i, expErr := range tt.expErrors {
+				require.ErrorContains(t, errs[i], expErr)
+			}
+		})
+	}
+}
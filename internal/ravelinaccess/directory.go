@@ -0,0 +1,322 @@
+package ravelinaccess
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Directory is the parsed content of an IAM directory. Every file is parsed
+// once and indexed, so that group files are not read again for each member.
+type Directory struct {
+	// Path is the root of the IAM directory.
+	Path string
+	// Settings are the naming rules the emails were derived with.
+	Settings Settings
+
+	users           []*RavelinAccess
+	serviceAccounts []*RavelinAccess
+	groups          map[string]*RavelinAccess
+	byEmail         map[string]*RavelinAccess
+	members         map[string][]*RavelinAccess
+	warnings        []string
+	violations      []string
+}
+
+// LoadDirectory parses the users, groups and service accounts of the IAM
+// directory concurrently. The settings file of the IAM directory is merged with
+// the given settings, which take precedence. The problems of every invalid file
+// are joined in the returned error, see Errors, and so are the violations of
+// the enforced policies of the IAM directory.
+func LoadDirectory(iamDirectory string, settings Settings) (*Directory, error) {
+	fileSettings, err := LoadSettings(iamDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	// the problems of the projects inventory and of the bundles are reported
+	// along with the ones of the entity files, which are still checked against
+	// what could be loaded of them
+	var errs []error
+	projects, err := loadProjects(iamDirectory)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	bundles, err := loadBundles(iamDirectory, projects)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	d := &Directory{
+		Path:     iamDirectory,
+		Settings: fileSettings.Merge(settings),
+		groups:   make(map[string]*RavelinAccess),
+		byEmail:  make(map[string]*RavelinAccess),
+		members:  make(map[string][]*RavelinAccess),
+	}
+
+	userFiles, err := GetUserFiles(iamDirectory)
+	if err != nil {
+		return nil, err
+	}
+	groupFiles, err := GetGroupFiles(iamDirectory)
+	if err != nil {
+		return nil, err
+	}
+	saFiles, err := GetServiceAccountFiles(iamDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, f := range userFiles {
+		files = append(files, filepath.Join(iamDirectory, "users", f))
+	}
+	for _, f := range groupFiles {
+		files = append(files, filepath.Join(iamDirectory, "groups", f))
+	}
+	for _, f := range saFiles {
+		files = append(files, filepath.Join(iamDirectory, "service-accounts", f))
+	}
+
+	// every file is parsed, even when some are invalid, so that all the problems
+	// of the directory are reported at once
+	entities := make([]*RavelinAccess, len(files))
+	fileErrs := make([]error, len(files))
+	g := errgroup.Group{}
+	g.SetLimit(runtime.GOMAXPROCS(0))
+	for i, file := range files {
+		g.Go(func() error {
+			access, err := extractRavelinAccess(file, d.Settings, bundles, projects)
+			if err != nil {
+				fileErrs[i] = err
+				return nil
+			}
+			access.directory = d
+			entities[i] = &access
+			return nil
+		})
+	}
+	_ = g.Wait()
+	if err := errors.Join(append(errs, fileErrs...)...); err != nil {
+		return nil, err
+	}
+
+	for _, entity := range entities {
+		if other, ok := d.byEmail[entity.Email]; ok {
+			return nil, fmt.Errorf("duplicate email %s in %s and %s", entity.Email, other.filePath, entity.filePath)
+		}
+		d.byEmail[entity.Email] = entity
+		d.warnings = append(d.warnings, entity.warnings...)
+
+		switch entity.Type {
+		case USER:
+			d.users = append(d.users, entity)
+		case SERVICE:
+			d.serviceAccounts = append(d.serviceAccounts, entity)
+		case GROUP:
+			name := groupName(entity.filePath)
+			if other, ok := d.groups[name]; ok {
+				return nil, fmt.Errorf("duplicate group %s in %s and %s", name, other.filePath, entity.filePath)
+			}
+			d.groups[name] = entity
+		}
+
+		for _, group := range entity.GCP.Groups {
+			d.members[group] = append(d.members[group], entity)
+		}
+	}
+
+	byEmail := func(a, b *RavelinAccess) int { return strings.Compare(a.Email, b.Email) }
+	slices.SortFunc(d.users, byEmail)
+	slices.SortFunc(d.serviceAccounts, byEmail)
+	for _, members := range d.members {
+		slices.SortFunc(members, byEmail)
+	}
+	slices.Sort(d.warnings)
+
+	policies, err := loadPolicies(iamDirectory, projects, d.groups)
+	if err != nil {
+		return nil, err
+	}
+	violations, err := policies.evaluate(d)
+	if err != nil {
+		return nil, err
+	}
+	if policies != nil && policies.audit() {
+		for _, violation := range violations {
+			d.violations = append(d.violations, violation.Error())
+		}
+	} else if len(violations) > 0 {
+		errs := make([]error, len(violations))
+		for i, violation := range violations {
+			errs[i] = violation
+		}
+		return nil, errors.Join(errs...)
+	}
+
+	return d, nil
+}
+
+// Entities returns copies of the active users, sorted by email, followed by the
+// active service accounts if includeServiceAccounts is set. Copies can be
+// modified, e.g. by inheritance, without altering the directory.
+func (d *Directory) Entities(includeServiceAccounts bool) []RavelinAccess {
+	entities := make([]RavelinAccess, 0, len(d.users)+len(d.serviceAccounts))
+	for _, user := range d.users {
+		if user.Active() {
+			entities = append(entities, user.clone())
+		}
+	}
+	if includeServiceAccounts {
+		for _, sa := range d.serviceAccounts {
+			if sa.Active() {
+				entities = append(entities, sa.clone())
+			}
+		}
+	}
+	return entities
+}
+
+// Inactive returns copies of the suspended and offboarded users, groups and,
+// if includeServiceAccounts is set, service accounts, sorted by email. They are
+// left out of every grant, but kept so that their access can be revoked.
+func (d *Directory) Inactive(includeServiceAccounts bool) []RavelinAccess {
+	var inactive []RavelinAccess
+	for _, entity := range d.byEmail {
+		if entity.Active() || (entity.Type == SERVICE && !includeServiceAccounts) {
+			continue
+		}
+		inactive = append(inactive, entity.clone())
+	}
+	slices.SortFunc(inactive, func(a, b RavelinAccess) int { return strings.Compare(a.Email, b.Email) })
+	return inactive
+}
+
+// ByEmail returns a copy of the user, group or service account with the given
+// email.
+func (d *Directory) ByEmail(email string) (RavelinAccess, bool) {
+	entity, ok := d.byEmail[email]
+	if !ok {
+		return RavelinAccess{}, false
+	}
+	return entity.clone(), true
+}
+
+// Group returns a copy of the group with the given name.
+func (d *Directory) Group(name string) (RavelinAccess, bool) {
+	group, ok := d.groups[name]
+	if !ok {
+		return RavelinAccess{}, false
+	}
+	return group.clone(), true
+}
+
+// GroupNames returns the names of the groups defined in the directory, sorted.
+func (d *Directory) GroupNames() []string {
+	names := make([]string, 0, len(d.groups))
+	for name := range d.groups {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Groups returns copies of the active groups defined in the directory, sorted
+// by name.
+func (d *Directory) Groups() []RavelinAccess {
+	groups := make([]RavelinAccess, 0, len(d.groups))
+	for _, name := range d.GroupNames() {
+		if group := d.groups[name]; group.Active() {
+			groups = append(groups, group.clone())
+		}
+	}
+	return groups
+}
+
+// Members returns the emails of the active users, service accounts and groups
+// listing the group in their groups, sorted.
+func (d *Directory) Members(group string) []string {
+	emails := make([]string, 0, len(d.members[group]))
+	for _, member := range d.members[group] {
+		if member.Active() {
+			emails = append(emails, member.Email)
+		}
+	}
+	return emails
+}
+
+// TwingateResources returns the Twingate Resources listed in the files of the
+// active entities of the directory mapped to the sorted Twingate groups allowed
+// to access them, the groups listed in the same files.
+func (d *Directory) TwingateResources() map[string][]string {
+	resources := make(map[string][]string)
+	for _, entity := range d.byEmail {
+		if !entity.Active() {
+			continue
+		}
+		for _, resource := range entity.Twingate.Resources {
+			resources[resource] = append(resources[resource], entity.Twingate.Groups...)
+		}
+	}
+	for resource, groups := range resources {
+		slices.Sort(groups)
+		resources[resource] = slices.Compact(groups)
+	}
+	return resources
+}
+
+// Warnings returns the warnings about the entries of the directory expiring
+// soon, sorted by file.
+func (d *Directory) Warnings() []string {
+	return d.warnings
+}
+
+// PolicyViolations returns the violations of the rules of the policies of the
+// IAM directory in audit mode, enforced policies failing LoadDirectory instead.
+func (d *Directory) PolicyViolations() []string {
+	return d.violations
+}
+
+// clone returns a copy of the access which doesn't share maps or slices with
+// the original.
+func (a *RavelinAccess) clone() RavelinAccess {
+	c := *a
+	c.GCP.Groups = slices.Clone(a.GCP.Groups)
+	c.GCP.GroupExpiries = maps.Clone(a.GCP.GroupExpiries)
+	if a.GCP.Roles != nil {
+		c.GCP.Roles = make(map[string][]string, len(a.GCP.Roles))
+		for project, roles := range a.GCP.Roles {
+			c.GCP.Roles[project] = slices.Clone(roles)
+		}
+	}
+	c.Twingate.Groups = slices.Clone(a.Twingate.Groups)
+	c.Twingate.Resources = slices.Clone(a.Twingate.Resources)
+	if a.Gsudo.Escalations != nil {
+		c.Gsudo.Escalations = make(map[string][]string, len(a.Gsudo.Escalations))
+		for project, roles := range a.Gsudo.Escalations {
+			c.Gsudo.Escalations[project] = slices.Clone(roles)
+		}
+	}
+	if a.Gsudo.Expiries != nil {
+		c.Gsudo.Expiries = make(map[string]map[string]time.Time, len(a.Gsudo.Expiries))
+		for project, roles := range a.Gsudo.Expiries {
+			c.Gsudo.Expiries[project] = maps.Clone(roles)
+		}
+	}
+	if a.Gsudo.Requirements != nil {
+		c.Gsudo.Requirements = make(map[string]EscalationRequirements, len(a.Gsudo.Requirements))
+		for project, requirements := range a.Gsudo.Requirements {
+			requirements.Approvers = slices.Clone(requirements.Approvers)
+			c.Gsudo.Requirements[project] = requirements
+		}
+	}
+	return c
+}
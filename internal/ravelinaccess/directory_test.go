@@ -0,0 +1,120 @@
+package ravelinaccess
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDirectory(t *testing.T) {
+	dir := createTempFiles(t, map[string][]byte{
+		"users/john_doe.yml":                     []byte("gcp:\n  groups:\n    - team\ngsudo:\n  inherit: true\n"),
+		"users/contractors/jane_doe.yaml":        []byte("gcp:\n  groups:\n    - team\n"),
+		"groups/team.yaml":                       []byte("gcp:\n  groups:\n    - engineering\n"),
+		"groups/engineering.yml":                 []byte("gsudo:\n  escalations:\n    project-a:\n      - roles/viewer\n"),
+		"service-accounts/my-project/ci-bot.yml": []byte("gcp:\n  groups:\n    - engineering\n"),
+	})
+
+	d, err := LoadDirectory(dir, Settings{})
+	require.NoError(t, err)
+
+	emails := func(entities []RavelinAccess) []string {
+		var out []string
+		for _, e := range entities {
+			out = append(out, e.Email)
+		}
+		return out
+	}
+	require.Equal(t, []string{"jane.doe@ravelin.com", "john.doe@ravelin.com"}, emails(d.Entities(false)))
+	require.Equal(t, []string{"jane.doe@ravelin.com", "john.doe@ravelin.com", "ci-bot@my-project.iam.gserviceaccount.com"}, emails(d.Entities(true)))
+
+	require.Equal(t, []string{"engineering", "team"}, d.GroupNames())
+	require.Equal(t, []string{"jane.doe@ravelin.com", "john.doe@ravelin.com"}, d.Members("team"))
+	require.Equal(t, []string{"ci-bot@my-project.iam.gserviceaccount.com", "gcp-team@ravelin.com"}, d.Members("engineering"))
+
+	group, ok := d.ByEmail("gcp-team@ravelin.com")
+	require.True(t, ok)
+	require.Equal(t, GROUP, group.Type)
+	_, ok = d.ByEmail("nobody@ravelin.com")
+	require.False(t, ok)
+
+	// inheritance resolves groups from the directory and doesn't alter it
+	user, ok := d.ByEmail("john.doe@ravelin.com")
+	require.True(t, ok)
+	require.NoError(t, user.InheritGsudoAccess())
+	require.Equal(t, map[string][]string{"project-a": {"roles/viewer"}}, user.Gsudo.Escalations)
+
+	user, _ = d.ByEmail("john.doe@ravelin.com")
+	require.Empty(t, user.Gsudo.Escalations)
+}
+
+func TestLoadDirectory_DuplicateGroup(t *testing.T) {
+	dir := createTempFiles(t, map[string][]byte{
+		"groups/team.yml":  []byte("gsudo: {}\n"),
+		"groups/team.yaml": []byte("gsudo: {}\n"),
+	})
+
+	_, err := LoadDirectory(dir, Settings{})
+	require.ErrorContains(t, err, "duplicate email gcp-team@ravelin.com")
+}
+
+func TestLoadDirectory_ReportsAllErrors(t *testing.T) {
+	dir := createTempFiles(t, map[string][]byte{
+		"users/john_doe.yml": []byte("gsudo:\n  inherrit: true\n"),
+		"users/jane_doe.yml": []byte("gcp:\n  group:\n    - team\n"),
+		"groups/team.yml":    []byte("gsudo:\n  escalations:\n    my-project:\n      - owner\n"),
+	})
+
+	_, err := LoadDirectory(dir, Settings{})
+	require.Len(t, Errors(err), 3)
+}
+
+func TestLoadDirectory_ReportsInventoryAndBundleErrors(t *testing.T) {
+	dir := createTempFiles(t, map[string][]byte{
+		"projects.yml":       []byte("my-project:\n  env: prod\n"),
+		"bundles/admin.yml":  []byte("roles:\n  - owner\n"),
+		"users/john_doe.yml": []byte("gsudo:\n  escalations:\n    my-project:\n      - bundle:admin\n"),
+		"users/jane_doe.yml": []byte("gsudo:\n  inherrit: true\n"),
+	})
+
+	// the entity files are still validated against the valid part of the
+	// inventory and the known bundles, so only the actual problems are reported
+	_, err := LoadDirectory(dir, Settings{})
+	errs := Errors(err)
+	require.Len(t, errs, 3)
+	require.ErrorContains(t, errs[0], `projects.yml:2:3: unknown field "env"`)
+	require.ErrorContains(t, errs[1], `bundles/admin.yml:2:5: invalid role "owner"`)
+	require.ErrorContains(t, errs[2], `users/jane_doe.yml:2:3: unknown field "inherrit"`)
+}
+
+func TestLoadDirectory_Inactive(t *testing.T) {
+	dir := createTempFiles(t, map[string][]byte{
+		"users/john_doe.yml":           []byte("gcp:\n  groups:\n    - team\n    - legacy\ngsudo:\n  inherit: true\n"),
+		"users/jane_doe.yml":           []byte("status: offboarded\noffboarded_at: 2026-09-30\ngcp:\n  groups:\n    - team\n"),
+		"users/jim_doe.yml":            []byte("status: suspended\n"),
+		"groups/team.yml":              []byte("gsudo:\n  escalations:\n    project-a:\n      - roles/viewer\n"),
+		"groups/legacy.yml":            []byte("status: suspended\ngsudo:\n  escalations:\n    project-a:\n      - roles/owner\n"),
+		"service-accounts/old-bot.yml": []byte("project: my-project\nstatus: offboarded\n"),
+	})
+
+	d, err := LoadDirectory(dir, Settings{})
+	require.NoError(t, err)
+
+	emails := func(entities []RavelinAccess) []string {
+		var out []string
+		for _, e := range entities {
+			out = append(out, e.Email)
+		}
+		return out
+	}
+	require.Equal(t, []string{"john.doe@ravelin.com"}, emails(d.Entities(true)))
+	require.Equal(t, []string{"gcp-team@ravelin.com"}, emails(d.Groups()))
+	require.Equal(t, []string{"gcp-legacy@ravelin.com", "jane.doe@ravelin.com", "jim.doe@ravelin.com"}, emails(d.Inactive(false)))
+	require.Len(t, d.Inactive(true), 4)
+	require.Equal(t, []string{"john.doe@ravelin.com"}, d.Members("team"))
+
+	// inactive groups grant no access
+	user, _ := d.ByEmail("john.doe@ravelin.com")
+	require.NoError(t, user.InheritGsudoAccess())
+	require.Equal(t, map[string][]string{"project-a": {"roles/viewer"}}, user.Gsudo.Escalations)
+}
@@ -0,0 +1,368 @@
+package ravelinaccess
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultExpiryWarningDays is the number of days before their expiry entries
+// are warned about when none is configured.
+const DefaultExpiryWarningDays = 14
+
+// now returns the current time, it is replaced in tests.
+var now = time.Now
+
+// parseExpiry parses an expiry, either a date, which expires at the start of
+// the day in UTC, or an RFC 3339 timestamp.
+func parseExpiry(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry %q, expected format: YYYY-MM-DD or RFC 3339", s)
+	}
+	return t.UTC(), nil
+}
+
+// expiry is an entry of an IAM file with an expiry. Escalations and group
+// memberships are recorded whether they expire or not, with a zero expiry for
+// the latter, so that a role or group granted by several entries only expires
+// with the last of them.
+type expiry struct {
+	// project and role of an escalation.
+	project, role string
+	// group of a group membership.
+	group string
+	// twingate is set for the expiry of the twingate block.
+	twingate bool
+
+	at           time.Time
+	line, column int
+}
+
+func (e expiry) String() string {
+	switch {
+	case e.twingate:
+		return "twingate access"
+	case e.group != "":
+		return fmt.Sprintf("membership of group %s", e.group)
+	}
+	return fmt.Sprintf("escalation to %s on %s", e.role, e.project)
+}
+
+// extractExpiries collects the expiries of the escalations, group memberships
+// and twingate block, along with the escalations and group memberships without
+// expiry. Escalations
+// and group memberships in their object form,
+// e.g. `{role: roles/owner, expires: 2026-11-30}`, are replaced by their role or
+// group so that the file decodes into lists of strings.
+func (v *validator) extractExpiries(root *yaml.Node) []expiry {
+	var expiries []expiry
+
+	if escalations := lookup(root, "gsudo", "escalations"); escalations != nil && escalations.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(escalations.Content); i += 2 {
+			project, roles := escalations.Content[i], escalations.Content[i+1]
+			if roles.Kind != yaml.SequenceNode {
+				continue
+			}
+			for j, item := range roles.Content {
+				if e, ok := v.objectEntry(item, "role"); ok {
+					roles.Content[j] = e.node
+					expiries = append(expiries, expiry{project: project.Value, role: e.node.Value, at: e.at, line: item.Line, column: item.Column})
+				} else if item.Kind == yaml.ScalarNode {
+					expiries = append(expiries, expiry{project: project.Value, role: item.Value, line: item.Line, column: item.Column})
+				}
+			}
+		}
+	}
+
+	if groups := lookup(root, "gcp", "groups"); groups != nil && groups.Kind == yaml.SequenceNode {
+		for j, item := range groups.Content {
+			if e, ok := v.objectEntry(item, "group"); ok {
+				groups.Content[j] = e.node
+				expiries = append(expiries, expiry{group: e.node.Value, at: e.at, line: item.Line, column: item.Column})
+			} else if item.Kind == yaml.ScalarNode {
+				expiries = append(expiries, expiry{group: item.Value, line: item.Line, column: item.Column})
+			}
+		}
+	}
+
+	if n := lookup(root, "twingate", "expires"); n != nil {
+		at, err := parseExpiry(n.Value)
+		if err != nil {
+			v.errorf(n, "%s", err)
+		} else {
+			expiries = append(expiries, expiry{twingate: true, at: at, line: n.Line, column: n.Column})
+		}
+	}
+	return expiries
+}
+
+// objectEntry is a list entry in its object form.
+type objectEntry struct {
+	// node is the scalar node of the role or group.
+	node *yaml.Node
+	// at is the expiry of the entry, if set.
+	at  time.Time
+	set bool
+}
+
+// objectEntry parses a list entry in its object form, a mapping with the key
+// field and an optional `expires`. It returns false if the entry is not a
+// mapping.
+func (v *validator) objectEntry(n *yaml.Node, key string) (objectEntry, bool) {
+	if n.Kind != yaml.MappingNode {
+		return objectEntry{}, false
+	}
+
+	// problems are reported and the entry replaced by an empty string, so the
+	// rest of the file is still validated
+	e := objectEntry{node: &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Line: n.Line, Column: n.Column}}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, value := n.Content[i], n.Content[i+1]
+		switch k.Value {
+		case key:
+			if value.Kind != yaml.ScalarNode {
+				v.errorf(value, "%s must be a string", key)
+				continue
+			}
+			e.node = value
+		case "expires":
+			at, err := parseExpiry(value.Value)
+			if err != nil {
+				v.errorf(value, "%s", err)
+				continue
+			}
+			e.at, e.set = at, true
+		default:
+			v.errorf(k, "unknown field %q, expected %s and expires", k.Value, key)
+		}
+	}
+	if e.node.Value == "" {
+		v.errorf(n, "missing %s", key)
+	}
+	return e, true
+}
+
+// applyExpiries drops the expired entries and records the expiry of the others.
+// Entries expiring within the warning window of the settings are warned about.
+// Escalations and group memberships are only dropped, or limited, once every
+// entry granting the role on the project, or the group, expired, or expires.
+func (a *RavelinAccess) applyExpiries(expiries []expiry) {
+	t := now()
+	days := a.settings.ExpiryWarningDays
+	if days == 0 {
+		days = DefaultExpiryWarningDays
+	}
+	warnBefore := t.AddDate(0, 0, days)
+
+	// expiries of the entries granting each role of each project and each
+	// group, the zero time meaning the entry doesn't expire
+	grants := make(map[string]map[string][]time.Time)
+	groups := make(map[string][]time.Time)
+	for _, e := range expiries {
+		switch {
+		case e.twingate:
+			continue
+		case e.group != "":
+			groups[e.group] = append(groups[e.group], e.at)
+			continue
+		}
+		for _, project := range a.projects.resolve(e.project) {
+			if grants[project] == nil {
+				grants[project] = make(map[string][]time.Time)
+			}
+			for _, role := range a.expandRole(project, e.role) {
+				grants[project][role] = append(grants[project][role], e.at)
+			}
+		}
+	}
+
+	for _, e := range expiries {
+		if e.at.IsZero() {
+			continue
+		}
+		if !e.at.After(t) {
+			if e.twingate {
+				a.Twingate = TwingateAccess{}
+			}
+			continue
+		}
+		if e.at.Before(warnBefore) {
+			a.warnings = append(a.warnings, fmt.Sprintf("%s:%d:%d: %s expires on %s", a.filePath, e.line, e.column, e, e.at.Format(time.RFC3339)))
+		}
+		if e.twingate {
+			a.Twingate.ExpiresAt = e.at
+		}
+	}
+
+	for project, roles := range grants {
+		for role, ats := range roles {
+			a.applyEscalationExpiry(project, role, ats, t)
+		}
+	}
+	for group, ats := range groups {
+		a.applyGroupExpiry(group, ats, t)
+	}
+}
+
+// lastExpiry returns the expiry of the last entry to expire after t, the zero
+// time if every entry expired. It returns false if one of the entries doesn't
+// expire.
+func lastExpiry(ats []time.Time, t time.Time) (time.Time, bool) {
+	var last time.Time
+	for _, at := range ats {
+		switch {
+		case at.IsZero():
+			return time.Time{}, false
+		case at.After(t) && at.After(last):
+			last = at
+		}
+	}
+	return last, true
+}
+
+// applyGroupExpiry drops the membership of the group if every entry listing it
+// expired, and otherwise records the expiry of the last entry to expire, unless
+// one of them doesn't expire.
+func (a *RavelinAccess) applyGroupExpiry(group string, ats []time.Time, t time.Time) {
+	last, expires := lastExpiry(ats, t)
+	switch {
+	case !expires:
+		return
+	case last.IsZero():
+		a.GCP.Groups = slices.DeleteFunc(a.GCP.Groups, func(g string) bool { return g == group })
+		return
+	}
+
+	if a.GCP.GroupExpiries == nil {
+		a.GCP.GroupExpiries = make(map[string]time.Time)
+	}
+	a.GCP.GroupExpiries[group] = last
+}
+
+// applyEscalationExpiry drops the role of the project if every entry granting
+// it expired, and otherwise records the expiry of the last entry to expire,
+// unless one of them doesn't expire.
+func (a *RavelinAccess) applyEscalationExpiry(project, role string, ats []time.Time, t time.Time) {
+	last, expires := lastExpiry(ats, t)
+	if !expires {
+		return
+	}
+
+	if last.IsZero() {
+		a.Gsudo.Escalations[project] = slices.DeleteFunc(a.Gsudo.Escalations[project], func(r string) bool { return r == role })
+		if len(a.Gsudo.Escalations[project]) == 0 {
+			delete(a.Gsudo.Escalations, project)
+		}
+		return
+	}
+
+	if a.Gsudo.Expiries == nil {
+		a.Gsudo.Expiries = make(map[string]map[string]time.Time)
+	}
+	if a.Gsudo.Expiries[project] == nil {
+		a.Gsudo.Expiries[project] = make(map[string]time.Time)
+	}
+	a.Gsudo.Expiries[project][role] = last
+}
+
+// Warnings returns the warnings about the entries of the file expiring soon.
+func (a *RavelinAccess) Warnings() []string {
+	return a.warnings
+}
+
+// earliest returns the earliest of the expiries, the zero time meaning no
+// expiry.
+func earliest(a, b time.Time) time.Time {
+	switch {
+	case a.IsZero():
+		return b
+	case b.IsZero():
+		return a
+	case b.Before(a):
+		return b
+	}
+	return a
+}
+
+// extendExpiry records that the role is granted until at, the zero time
+// meaning no expiry. A role granted by several entries expires with the last
+// one, and never if any of them doesn't expire. It must be called before the
+// role is added to the escalations.
+func (g *GsudoAccess) extendExpiry(project, role string, at time.Time) {
+	granted := slices.Contains(g.Escalations[project], role)
+	current, limited := g.Expiries[project][role]
+
+	switch {
+	case granted && !limited:
+		return
+	case at.IsZero():
+		if limited {
+			delete(g.Expiries[project], role)
+			if len(g.Expiries[project]) == 0 {
+				delete(g.Expiries, project)
+			}
+		}
+	case !granted || at.After(current):
+		if g.Expiries == nil {
+			g.Expiries = make(map[string]map[string]time.Time)
+		}
+		if g.Expiries[project] == nil {
+			g.Expiries[project] = make(map[string]time.Time)
+		}
+		g.Expiries[project][role] = at
+	}
+}
+
+// membershipExpiries returns the expiry of the membership of the entity to each
+// group of its closure, by group file. Memberships through nested groups expire
+// with the first membership of the chain to expire, and memberships through
+// several chains with the last chain to expire. The zero time means no expiry.
+func (a *RavelinAccess) membershipExpiries(closure []*RavelinAccess) map[*RavelinAccess]time.Time {
+	byName := make(map[string]*RavelinAccess, len(closure))
+	for _, group := range closure {
+		byName[groupName(group.filePath)] = group
+	}
+
+	expiries := make(map[*RavelinAccess]time.Time, len(closure))
+	extend := func(group *RavelinAccess, at time.Time) bool {
+		current, ok := expiries[group]
+		if ok && (current.IsZero() || (!at.IsZero() && !at.After(current))) {
+			return false
+		}
+		expiries[group] = at
+		return true
+	}
+
+	for _, name := range a.GCP.Groups {
+		if group, ok := byName[name]; ok {
+			extend(group, a.GCP.GroupExpiries[name])
+		}
+	}
+
+	// the group graph has no cycles, so expiries settle after at most one pass
+	// per group
+	for range closure {
+		changed := false
+		for _, group := range closure {
+			at, ok := expiries[group]
+			if !ok {
+				continue
+			}
+			for _, name := range group.GCP.Groups {
+				if parent, ok := byName[name]; ok && extend(parent, earliest(at, group.GCP.GroupExpiries[name])) {
+					changed = true
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return expiries
+}
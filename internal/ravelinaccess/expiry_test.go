@@ -0,0 +1,226 @@
+package ravelinaccess
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setNow(t *testing.T, at time.Time) {
+	t.Helper()
+	previous := now
+	now = func() time.Time { return at }
+	t.Cleanup(func() { now = previous })
+}
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestExpiries(t *testing.T) {
+	setNow(t, date("2026-11-20"))
+
+	dir := createTempFiles(t, map[string][]byte{
+		"users/john_doe.yml": []byte(`gcp:
+  groups:
+    - team
+    - group: oncall
+      expires: 2026-12-31
+    - group: incident
+      expires: 2026-11-01
+gsudo:
+  inherit: true
+  escalations:
+    my-project:
+      - roles/viewer
+      - role: roles/owner
+        expires: 2026-11-30
+      - role: custom/deployer
+        expires: 2026-12-15T12:00:00Z
+      - role: roles/editor
+        expires: 2026-10-01
+twingate:
+  enabled: true
+  expires: 2027-01-31
+`),
+		"groups/team.yml": []byte(`gsudo:
+  escalations:
+    my-project:
+      - roles/owner
+`),
+		"groups/oncall.yml": []byte(`gcp:
+  groups:
+    - responders
+gsudo:
+  escalations:
+    other-project:
+      - roles/editor
+      - role: roles/owner
+        expires: 2027-01-31
+`),
+		"groups/responders.yml": []byte(`gsudo:
+  escalations:
+    other-project:
+      - roles/viewer
+`),
+		"groups/incident.yml": []byte(`gsudo:
+  escalations:
+    incident-project:
+      - roles/owner
+`),
+	})
+
+	d, err := LoadDirectory(dir, Settings{})
+	require.NoError(t, err)
+
+	user, ok := d.ByEmail("john.doe@ravelin.com")
+	require.True(t, ok)
+	require.Equal(t, []string{"team", "oncall"}, user.GCP.Groups)
+	require.Equal(t, map[string]time.Time{"oncall": date("2026-12-31")}, user.GCP.GroupExpiries)
+	require.Equal(t, date("2027-01-31"), user.Twingate.ExpiresAt)
+
+	// the expiry warnings only include the entries expiring in the next 14 days
+	require.Equal(t, []string{
+		filepath.Join(dir, "users/john_doe.yml") + ":13:9: escalation to roles/owner on my-project expires on 2026-11-30T00:00:00Z",
+	}, d.Warnings())
+
+	require.NoError(t, user.InheritGsudoAccess())
+	require.Equal(t, map[string][]string{
+		"my-project":    {"projects/my-project/roles/deployer", "roles/owner", "roles/viewer"},
+		"other-project": {"roles/editor", "roles/owner", "roles/viewer"},
+	}, user.Gsudo.Escalations)
+
+	// roles/owner is also granted by the team group without expiry, roles
+	// inherited from oncall and responders expire with the membership to oncall
+	require.Equal(t, map[string]map[string]time.Time{
+		"my-project": {"projects/my-project/roles/deployer": time.Date(2026, 12, 15, 12, 0, 0, 0, time.UTC)},
+		"other-project": {
+			"roles/editor": date("2026-12-31"),
+			"roles/owner":  date("2026-12-31"),
+			"roles/viewer": date("2026-12-31"),
+		},
+	}, user.Gsudo.Expiries)
+}
+
+func TestExpiries_TwingateInheritance(t *testing.T) {
+	setNow(t, date("2026-11-20"))
+
+	dir := createTempFiles(t, map[string][]byte{
+		"users/john_doe.yml": []byte(`gcp:
+  groups:
+    - group: contractors
+      expires: 2027-03-01
+`),
+		"users/jane_doe.yml": []byte(`twingate:
+  enabled: true
+  admin: true
+  expires: 2026-11-01
+`),
+		"groups/contractors.yml": []byte(`twingate:
+  enabled: true
+  expires: 2027-02-01
+`),
+	})
+
+	john, err := ExtractRavelinAccess(filepath.Join(dir, "users/john_doe.yml"), Settings{})
+	require.NoError(t, err)
+	require.NoError(t, john.InheritTwingateAccess())
+	require.True(t, *john.Twingate.Enabled)
+	require.Equal(t, date("2027-02-01"), john.Twingate.ExpiresAt)
+
+	jane, err := ExtractRavelinAccess(filepath.Join(dir, "users/jane_doe.yml"), Settings{})
+	require.NoError(t, err)
+	require.Equal(t, TwingateAccess{}, jane.Twingate)
+}
+
+func TestExpiries_RoleGrantedSeveralTimes(t *testing.T) {
+	setNow(t, date("2026-11-20"))
+
+	dir := createTempFiles(t, map[string][]byte{
+		"users/john_doe.yml": []byte(`gsudo:
+  escalations:
+    my-project:
+      - role: roles/owner
+        expires: 2026-10-01
+      - roles/owner
+      - role: roles/editor
+        expires: 2026-10-01
+      - role: roles/editor
+        expires: 2026-12-31
+      - role: roles/editor
+        expires: 2026-12-01
+      - role: roles/viewer
+        expires: 2026-10-01
+      - role: roles/viewer
+        expires: 2026-11-01
+`),
+	})
+
+	john, err := ExtractRavelinAccess(filepath.Join(dir, "users/john_doe.yml"), Settings{})
+	require.NoError(t, err)
+
+	// a role is kept while any entry still grants it, and expires with the last
+	// of them, never if one of them doesn't expire
+	require.Contains(t, john.Gsudo.Escalations["my-project"], "roles/owner")
+	require.Contains(t, john.Gsudo.Escalations["my-project"], "roles/editor")
+	require.NotContains(t, john.Gsudo.Escalations["my-project"], "roles/viewer")
+	require.Equal(t, map[string]map[string]time.Time{"my-project": {"roles/editor": date("2026-12-31")}}, john.Gsudo.Expiries)
+}
+
+func TestExpiries_GroupListedSeveralTimes(t *testing.T) {
+	setNow(t, date("2026-11-20"))
+
+	dir := createTempFiles(t, map[string][]byte{
+		"users/john_doe.yml": []byte(`gcp:
+  groups:
+    - group: oncall
+      expires: 2026-10-01
+    - oncall
+    - group: team
+      expires: 2026-10-01
+    - group: team
+      expires: 2026-12-31
+    - group: team
+      expires: 2026-12-01
+    - group: incident
+      expires: 2026-10-01
+    - group: incident
+      expires: 2026-11-01
+`),
+	})
+
+	john, err := ExtractRavelinAccess(filepath.Join(dir, "users/john_doe.yml"), Settings{})
+	require.NoError(t, err)
+
+	// a membership is kept while any entry still lists the group, and expires
+	// with the last of them, never if one of them doesn't expire
+	require.Contains(t, john.GCP.Groups, "oncall")
+	require.Contains(t, john.GCP.Groups, "team")
+	require.NotContains(t, john.GCP.Groups, "incident")
+	require.Equal(t, map[string]time.Time{"team": date("2026-12-31")}, john.GCP.GroupExpiries)
+}
+
+func TestExpiries_Invalid(t *testing.T) {
+	dir := createTempFiles(t, map[string][]byte{
+		"users/john_doe.yml": []byte(`gsudo:
+  escalations:
+    my-project:
+      - role: roles/owner
+        expires: next week
+      - rol: roles/owner
+`),
+	})
+
+	_, err := ExtractRavelinAccess(filepath.Join(dir, "users/john_doe.yml"), Settings{})
+	errs := Errors(err)
+	require.Len(t, errs, 3)
+	require.ErrorContains(t, errs[0], `:5:18: invalid expiry "next week"`)
+	require.ErrorContains(t, errs[1], `:6:9: unknown field "rol", expected role and expires`)
+	require.ErrorContains(t, errs[2], `:6:9: missing role`)
+}
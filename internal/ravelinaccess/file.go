@@ -3,8 +3,11 @@ package ravelinaccess
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -20,57 +23,144 @@ func readFile(filePath string) ([]byte, error) {
 	return data, nil
 }
 
-// GetUserFiles returns a list of user files from the IAM directory
+// yamlExtensions are the extensions of the IAM YAML files.
+var yamlExtensions = []string{".yml", ".yaml"}
+
+// entityDirs maps the directories of the IAM directory to the type of entity
+// defined by the files they contain.
+var entityDirs = map[string]EntityType{
+	"users":            USER,
+	"groups":           GROUP,
+	"service-accounts": SERVICE,
+}
+
+// GetUserFiles returns a list of user files from the IAM directory. Files in
+// subdirectories are returned with their path relative to the users directory.
 func GetUserFiles(iamDirectory string) ([]string, error) {
-	files, err := os.ReadDir(filepath.Join(iamDirectory, "users"))
-	if err != nil {
-		return nil, fmt.Errorf("error retrieving a list of user files from IAM directory: %v", err)
+	return getEntityFiles(iamDirectory, "users")
+}
+
+// getEntityFiles returns the yaml files found in the given entity directory of
+// the IAM directory, with their path relative to the entity directory.
+func getEntityFiles(iamDirectory, entityDir string) ([]string, error) {
+	root := filepath.Join(iamDirectory, entityDir)
+	if _, err := os.Stat(root); err != nil {
+		return nil, fmt.Errorf("error retrieving a list of %s files from IAM directory: %v", entityDir, err)
 	}
 
-	var userFiles []string
-	for _, file := range files {
-		if !file.IsDir() && (strings.HasSuffix(file.Name(), ".yml") || strings.HasSuffix(file.Name(), ".yaml")) {
-			userFiles = append(userFiles, file.Name())
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !slices.Contains(yamlExtensions, filepath.Ext(d.Name())) {
+			return nil
 		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving a list of %s files from IAM directory: %v", entityDir, err)
 	}
 
-	return userFiles, nil
+	return files, nil
 }
 
-// fileToType returns the type of entity based on the path of the yaml file
+// fileToType returns the type of entity based on the path of the yaml file,
+// the closest users, groups or service-accounts parent directory wins.
 func fileToType(file string) (EntityType, error) {
-	switch {
-	case filepath.Dir(file) == "users" || strings.HasSuffix(filepath.Dir(file), "/users"):
-		return USER, nil
-	case filepath.Dir(file) == "service-accounts" || strings.HasSuffix(filepath.Dir(file), "/service-accounts"):
-		return SERVICE, nil
-	case filepath.Dir(file) == "groups" || strings.HasSuffix(filepath.Dir(file), "/groups"):
-		return GROUP, nil
+	for dir := filepath.Dir(file); dir != "." && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if typ, ok := entityDirs[filepath.Base(dir)]; ok {
+			return typ, nil
+		}
 	}
 
 	return -1, errors.New("unable to determine type of file")
 }
 
+// groupName returns the name of the group defined in the file, its base name
+// without extension.
+func groupName(file string) string {
+	return strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+}
+
+// GetGroupFiles returns a list of group files from the IAM directory, with their
+// path relative to the groups directory. The groups directory is optional.
+func GetGroupFiles(iamDirectory string) ([]string, error) {
+	if _, err := os.Stat(filepath.Join(iamDirectory, "groups")); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return getEntityFiles(iamDirectory, "groups")
+}
+
+// GetServiceAccountFiles returns a list of service account files from the IAM
+// directory, with their path relative to the service-accounts directory. The
+// service-accounts directory is optional.
+func GetServiceAccountFiles(iamDirectory string) ([]string, error) {
+	if _, err := os.Stat(filepath.Join(iamDirectory, "service-accounts")); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return getEntityFiles(iamDirectory, "service-accounts")
+}
+
+// iamRoot returns the root of the IAM directory the file belongs to, which is
+// the parent of its users, groups or service-accounts directory.
+func iamRoot(file string) string {
+	for dir := filepath.Dir(file); dir != "." && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if _, ok := entityDirs[filepath.Base(dir)]; ok {
+			return filepath.Dir(dir)
+		}
+	}
+	return filepath.Join(filepath.Dir(file), "..")
+}
+
 // fileToEmail returns the email of the entity based on the path of the yaml file
-func fileToEmail(file string, typ EntityType) (string, error) {
+// and the naming rules of the settings. The project is only used for service
+// accounts, it overrides the project derived from the file path.
+func fileToEmail(file string, typ EntityType, settings Settings, project string) (string, error) {
 	switch typ {
 	case USER:
-		parts := strings.Split(filepath.Base(file), ".")
-		if len(parts) != 2 {
-			return "", fmt.Errorf("invalid user file: %s, expected format: <name>_<surname>.yml", file)
-		}
-		return strings.ReplaceAll(parts[0], "_", ".") + "@ravelin.com", nil
+		return settings.userEmail(file)
 
 	case GROUP:
-		parts := strings.Split(filepath.Base(file), ".")
-		if len(parts) != 2 {
-			return "", fmt.Errorf("invalid group file: %s, expected format: <group-name>.yml", file)
-		}
-		return "gcp-" + parts[0] + "@ravelin.com", nil
+		return settings.groupEmail(file)
 
 	case SERVICE:
-		return "", errors.New("service accounts are not yet supported")
+		return serviceAccountEmail(file, project)
 	}
 
 	return "", fmt.Errorf("invalid entity type for file: %s", file)
 }
+
+// serviceAccountRegexp matches valid service account IDs, the local part of
+// service account emails.
+var serviceAccountRegexp = regexp.MustCompile(`^[a-z]([-a-z0-9]{4,28}[a-z0-9])$`)
+
+// serviceAccountEmail returns the email of the service account defined in the
+// given file, either service-accounts/<project>/<name>.yml or
+// service-accounts/<name>.yml with the project set in the file.
+func serviceAccountEmail(file, project string) (string, error) {
+	parts := strings.Split(filepath.Base(file), ".")
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid service account file: %s, expected format: <project>/<name>.yml", file)
+	}
+	name := parts[0]
+
+	if project == "" {
+		if rel, ok := entityRelativeDir(file); ok && filepath.Dir(rel) == "service-accounts" {
+			project = filepath.Base(rel)
+		}
+	}
+	if project == "" {
+		return "", fmt.Errorf("unable to determine the project of service account file %s, move it to service-accounts/<project>/ or set the `project` key", file)
+	}
+
+	if !serviceAccountRegexp.MatchString(name) {
+		return "", fmt.Errorf("invalid service account name %q in file %s, it must be 6 to 30 lowercase letters, digits or hyphens", name, file)
+	}
+	return name + "@" + project + ".iam.gserviceaccount.com", nil
+}
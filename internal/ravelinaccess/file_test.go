@@ -1,6 +1,9 @@
 package ravelinaccess
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestFileToType(t *testing.T) {
 	tests := []struct {
@@ -14,6 +17,8 @@ func TestFileToType(t *testing.T) {
 		{file: "path/users/john_doe.yml", want: USER},
 		{file: "path/service-accounts/john_doe.yml", want: SERVICE},
 		{file: "path/groups/john_doe.yml", want: GROUP},
+		{file: "path/users/contractors/john_doe.yml", want: USER},
+		{file: "path/groups/eng/platform.yml", want: GROUP},
 		{file: "path/john_doe.yml", want: -1, wantErr: true},
 	}
 
@@ -57,7 +62,63 @@ func TestUserFileToEmail(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			out, err := fileToEmail(tt.input, tt.typ)
+			out, err := fileToEmail(tt.input, tt.typ, Settings{}, "")
+			if err != nil {
+				t.Errorf("fileToEmail - error: %v", err)
+			}
+			if out != tt.expOutput {
+				t.Errorf("fileToEmail - expected %s but got %s", tt.expOutput, out)
+			}
+		})
+	}
+}
+
+func TestServiceAccountFileToEmail(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		project   string
+		expOutput string
+		expError  string
+	}{
+		{
+			name:      "project_directory",
+			input:     "/mnt/c/iam/service-accounts/my-project/deployer.yml",
+			expOutput: "deployer@my-project.iam.gserviceaccount.com",
+		},
+		{
+			name:      "project_key",
+			input:     "/mnt/c/iam/service-accounts/deployer.yml",
+			project:   "my-project",
+			expOutput: "deployer@my-project.iam.gserviceaccount.com",
+		},
+		{
+			name:      "project_key_overrides_directory",
+			input:     "/mnt/c/iam/service-accounts/my-project/deployer.yml",
+			project:   "other-project",
+			expOutput: "deployer@other-project.iam.gserviceaccount.com",
+		},
+		{
+			name:     "missing_project",
+			input:    "/mnt/c/iam/service-accounts/deployer.yml",
+			expError: "unable to determine the project",
+		},
+		{
+			name:     "invalid_name",
+			input:    "/mnt/c/iam/service-accounts/my-project/ci.yml",
+			expError: "invalid service account name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := fileToEmail(tt.input, SERVICE, Settings{}, tt.project)
+			if tt.expError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expError) {
+					t.Errorf("fileToEmail - expected error %q but got %v", tt.expError, err)
+				}
+				return
+			}
 			if err != nil {
 				t.Errorf("fileToEmail - error: %v", err)
 			}
@@ -0,0 +1,145 @@
+package ravelinaccess
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DefaultMaxGroupDepth is the maximum nesting depth of groups when none is
+// configured, direct groups being at depth 1.
+const DefaultMaxGroupDepth = 10
+
+// loadGroup returns the group with the given name. Groups are looked up in the
+// directory the entity was loaded from, or read from the groups directory of the
+// IAM directory of the entity. It returns nil if the group has no file or is
+// not active, inactive groups granting no access.
+func (a *RavelinAccess) loadGroup(name string) (*RavelinAccess, error) {
+	if a.directory != nil {
+		if group := a.directory.groups[name]; group != nil && group.Active() {
+			return group, nil
+		}
+		return nil, nil
+	}
+
+	for _, ext := range yamlExtensions {
+		groupFile := filepath.Join(iamRoot(a.filePath), "groups", name+ext)
+		if _, err := os.Stat(groupFile); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		group, err := extractRavelinAccess(groupFile, a.settings, a.bundles, a.projects)
+		if err != nil {
+			return nil, fmt.Errorf("error extracting group access from %s: %w", groupFile, err)
+		}
+		if !group.Active() {
+			return nil, nil
+		}
+		return &group, nil
+	}
+	return nil, nil
+}
+
+// AllGroups returns the names of the groups the entity belongs to, directly or
+// through nested groups, sorted.
+func (a *RavelinAccess) AllGroups() ([]string, error) {
+	closure, err := a.groupClosure(a.GCP.Groups)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving groups of %s: %w", a.Email, err)
+	}
+	// groups without a file are not part of the closure
+	groups := slices.Clone(a.GCP.Groups)
+	for _, group := range closure {
+		groups = append(groups, groupName(group.filePath))
+	}
+	slices.Sort(groups)
+	return slices.Compact(groups), nil
+}
+
+// groupClosure returns the groups the given groups belong to, directly or
+// through other groups, ordered from the nearest to the farthest. Groups
+// reachable through several paths are only returned once, at their nearest
+// position. Groups without a file are skipped.
+func (a *RavelinAccess) groupClosure(groups []string) ([]*RavelinAccess, error) {
+	maxDepth := a.settings.MaxGroupDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxGroupDepth
+	}
+
+	loaded := make(map[string]*RavelinAccess)
+	load := func(name string) (*RavelinAccess, error) {
+		if group, ok := loaded[name]; ok {
+			return group, nil
+		}
+		group, err := a.loadGroup(name)
+		if err != nil {
+			return nil, err
+		}
+		loaded[name] = group
+		return group, nil
+	}
+
+	// walk the group graph depth first to detect cycles and groups nested too
+	// deeply, heights are memoised so shared parent groups are only walked once
+	heights := make(map[string]int)
+	var visit func(name string, path []string) (int, error)
+	visit = func(name string, path []string) (int, error) {
+		if i := slices.Index(path, name); i != -1 {
+			return 0, fmt.Errorf("group cycle detected: %s", strings.Join(append(path[i:], name), " -> "))
+		}
+		path = append(path, name)
+
+		height, ok := heights[name]
+		if !ok {
+			group, err := load(name)
+			if err != nil {
+				return 0, err
+			}
+			height = 1
+			if group != nil {
+				for _, parent := range group.GCP.Groups {
+					h, err := visit(parent, path)
+					if err != nil {
+						return 0, err
+					}
+					height = max(height, h+1)
+				}
+			}
+			heights[name] = height
+		}
+
+		if len(path)-1+height > maxDepth {
+			return 0, fmt.Errorf("groups are nested more than %d levels deep: %s", maxDepth, strings.Join(path, " -> "))
+		}
+		return height, nil
+	}
+	for _, name := range groups {
+		if _, err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// then breadth first, so nearer groups come first
+	var closure []*RavelinAccess
+	seen := make(map[string]bool)
+	queue := slices.Clone(groups)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		group := loaded[name]
+		if group == nil {
+			continue
+		}
+		closure = append(closure, group)
+		queue = append(queue, group.GCP.Groups...)
+	}
+	return closure, nil
+}
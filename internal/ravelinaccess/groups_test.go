@@ -0,0 +1,140 @@
+package ravelinaccess
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupClosure(t *testing.T) {
+	tests := []struct {
+		name     string
+		groups   map[string][]byte
+		settings Settings
+		expected []string
+		expError string
+	}{
+		{
+			name: "nested_groups_nearest_first",
+			groups: map[string][]byte{
+				"groups/team.yml":        []byte("gcp:\n  groups:\n    - engineering\n"),
+				"groups/oncall.yml":      []byte("gcp:\n  groups:\n    - engineering\n"),
+				"groups/engineering.yml": []byte("gcp:\n  groups:\n    - everyone\n"),
+				"groups/everyone.yml":    []byte("gsudo: {}\n"),
+			},
+			expected: []string{"gcp-team@ravelin.com", "gcp-oncall@ravelin.com", "gcp-engineering@ravelin.com", "gcp-everyone@ravelin.com"},
+		},
+		{
+			name: "missing_parent_group_is_ignored",
+			groups: map[string][]byte{
+				"groups/team.yml": []byte("gcp:\n  groups:\n    - missing\n"),
+			},
+			expected: []string{"gcp-team@ravelin.com"},
+		},
+		{
+			name: "cycle",
+			groups: map[string][]byte{
+				"groups/team.yml":        []byte("gcp:\n  groups:\n    - engineering\n"),
+				"groups/engineering.yml": []byte("gcp:\n  groups:\n    - everyone\n"),
+				"groups/everyone.yml":    []byte("gcp:\n  groups:\n    - team\n"),
+			},
+			expError: "group cycle detected: team -> engineering -> everyone -> team",
+		},
+		{
+			name: "max_depth",
+			groups: map[string][]byte{
+				"groups/team.yml":        []byte("gcp:\n  groups:\n    - engineering\n"),
+				"groups/engineering.yml": []byte("gcp:\n  groups:\n    - everyone\n"),
+				"groups/everyone.yml":    []byte("gsudo: {}\n"),
+			},
+			settings: Settings{MaxGroupDepth: 2},
+			expError: "groups are nested more than 2 levels deep: team -> engineering -> everyone",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := createTempFiles(t, tt.groups)
+			user := RavelinAccess{Type: USER, filePath: filepath.Join(dir, "users", "john_doe.yml"), settings: tt.settings}
+
+			closure, err := user.groupClosure([]string{"team", "oncall"})
+			if tt.expError != "" {
+				require.ErrorContains(t, err, tt.expError)
+				return
+			}
+			require.NoError(t, err)
+
+			var emails []string
+			for _, group := range closure {
+				emails = append(emails, group.Email)
+			}
+			require.Equal(t, tt.expected, emails)
+		})
+	}
+}
+
+func TestNestedGroupInheritance(t *testing.T) {
+	boolPtr := func(v bool) *bool { return &v }
+
+	dir := createTempFiles(t, map[string][]byte{
+		"users/john_doe.yml": []byte(`
+gcp:
+  groups:
+    - team
+gsudo:
+  inherit: true
+  escalations:
+    project1:
+      - roles/viewer
+`),
+		"groups/team.yml": []byte(`
+gcp:
+  groups:
+    - engineering
+gsudo:
+  escalations:
+    project2:
+      - roles/editor
+twingate:
+  admin: false
+`),
+		"groups/engineering.yml": []byte(`
+gsudo:
+  access-policies: true
+  escalations:
+    project1:
+      - roles/owner
+twingate:
+  enabled: true
+  admin: true
+`),
+	})
+
+	access, err := ExtractRavelinAccess(filepath.Join(dir, "users", "john_doe.yml"), Settings{})
+	require.NoError(t, err)
+
+	require.NoError(t, access.InheritGsudoAccess())
+	expected := GsudoAccess{
+		Inherit:        true,
+		AccessPolicies: boolPtr(true),
+		Escalations: map[string][]string{
+			"project1": {"roles/owner", "roles/viewer"},
+			"project2": {"roles/editor"},
+		},
+	}
+	if diff := cmp.Diff(expected, access.Gsudo, cmpopts.IgnoreFields(GsudoAccess{}, "Sources", "AccessPoliciesSource")); diff != "" {
+		t.Errorf("expected gsudo access (-) but got (+), %s", diff)
+	}
+
+	// the nearest group disables admin access granted by its parent group
+	require.NoError(t, access.InheritTwingateAccess())
+	require.Equal(t, TwingateAccess{
+		Enabled:       boolPtr(true),
+		Admin:         boolPtr(false),
+		EnabledSource: &Source{Group: "engineering", File: filepath.Join(dir, "groups", "engineering.yml")},
+		AdminSource:   &Source{Group: "team", File: filepath.Join(dir, "groups", "team.yml")},
+	}, access.Twingate)
+}
@@ -3,27 +3,58 @@ package ravelinaccess
 import (
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"time"
 )
 
 // GsudoAccess represents the gsudo configuration for a user or a group.
 type GsudoAccess struct {
-	// Escalations is a map of project names to a list of escalation roles.
+	// Escalations is a map of project names to a list of escalation roles. The
+	// project selectors of the file are expanded to the projects they match.
+	// The roles of a project can also be listed in the object form of the
+	// escalations, along with their requirements.
 	Escalations map[string][]string `yaml:"escalations"`
-	// Inherit indicates if escalations are inherited from the user's group.
-	// Inheritance is only supported for users.
+	// Inherit indicates if escalations are inherited from the user's groups,
+	// including the groups they belong to.
+	// Inheritance is only supported for users and service accounts.
 	Inherit bool `yaml:"inherit"`
 	// AccessPolicies indicates if the user or group has access to modify the access
 	// policies user bindings via gsudo. Note that this is a pointer as we might want
 	// to have "false" over a user override a group level access.
 	AccessPolicies *bool `yaml:"access-policies,omitempty"`
+	// Expiries maps projects to the escalation roles with an expiry, set with
+	// the `{role: <role>, expires: <date>}` form of the escalation entries, to
+	// their expiry. Expired roles are dropped from the escalations, and inherited
+	// roles expire with the membership to the group granting them.
+	Expiries map[string]map[string]time.Time `yaml:"-"`
+	// Requirements maps projects to the requirements of the escalations to
+	// their roles, set with the object form of the escalations. Requirements
+	// inherited from groups are merged, the most restrictive value winning.
+	Requirements map[string]EscalationRequirements `yaml:"-"`
+
+	// Sources maps projects to the escalation roles to the files granting them,
+	// the user file first, then the groups from the nearest to the farthest. It
+	// is set by InheritGsudoAccess.
+	Sources map[string]map[string][]Source `yaml:"-"`
+	// AccessPoliciesSource is the file deciding access policies, nil if no file
+	// sets it. It is set by InheritGsudoAccess.
+	AccessPoliciesSource *Source `yaml:"-"`
 }
 
-// InheritGsudoAccess inherits the gsudo escalations from the list of groups the user belongs to.
+// InheritGsudoAccess inherits the gsudo escalations from the groups the user
+// belongs to, directly or through nested groups. Settings at the user level take
+// precedence over the ones of the nearest group.
 func (a *RavelinAccess) InheritGsudoAccess() error {
-	if a.Type != USER {
-		return errors.New("inheritance is only available for users")
+	if a.Type == GROUP {
+		return errors.New("inheritance is only available for users and service accounts")
+	}
+
+	for project, roles := range a.Gsudo.Escalations {
+		for _, role := range roles {
+			a.Gsudo.addSource(project, role, a.userSource())
+		}
+	}
+	if a.Gsudo.AccessPolicies != nil {
+		a.Gsudo.AccessPoliciesSource = a.userSource()
 	}
 
 	// nothing to do if we don't want to inherit group level escalations
@@ -31,27 +62,33 @@ func (a *RavelinAccess) InheritGsudoAccess() error {
 		return nil
 	}
 
-	for _, group := range a.GCP.Groups {
-		groupFile := filepath.Join(filepath.Dir(a.filePath), "..", "groups", group+".yml")
-		groupYaml, err := readFile(groupFile)
-		if err != nil {
-			if errors.Is(err, os.ErrNotExist) {
-				continue
-			}
-			return fmt.Errorf("error reading group file %s: %w", groupFile, err)
-		}
+	// groups are ordered from the nearest to the farthest, so the nearest group
+	// setting access policies wins when the user doesn't
+	groups, err := a.groupClosure(a.GCP.Groups)
+	if err != nil {
+		return fmt.Errorf("error resolving groups of %s: %w", a.Email, err)
+	}
 
-		var group RavelinAccess
-		if err := group.extractAccess(groupYaml); err != nil {
-			return fmt.Errorf("error extracting group access: %w", err)
+	memberships := a.membershipExpiries(groups)
+	for _, group := range groups {
+		for project, roles := range group.Gsudo.Escalations {
+			for _, role := range roles {
+				a.Gsudo.extendExpiry(project, role, earliest(memberships[group], group.Gsudo.Expiries[project][role]))
+				a.Gsudo.addSource(project, role, groupSource(group))
+			}
 		}
-
 		a.Gsudo.Escalations = mergeMapsOfSlices(a.Gsudo.Escalations, group.Gsudo.Escalations)
+		for project, requirements := range group.Gsudo.Requirements {
+			if a.Gsudo.Requirements == nil {
+				a.Gsudo.Requirements = make(map[string]EscalationRequirements)
+			}
+			a.Gsudo.Requirements[project] = a.Gsudo.Requirements[project].merge(requirements)
+		}
 
-		if a.Gsudo.AccessPolicies == nil {
+		if a.Gsudo.AccessPolicies == nil && group.Gsudo.AccessPolicies != nil {
 			a.Gsudo.AccessPolicies = group.Gsudo.AccessPolicies
+			a.Gsudo.AccessPoliciesSource = groupSource(group)
 		}
-
 	}
 
 	return nil
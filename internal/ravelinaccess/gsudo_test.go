@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/stretchr/testify/require"
 )
 
@@ -166,7 +167,7 @@ gsudo:
 		t.Run(tt.name, func(t *testing.T) {
 			tempDir := createTempFiles(t, tt.userFile, tt.groupFiles)
 
-			access, err := ExtractRavelinAccess(filepath.Join(tempDir, "users", "john_doe.yml"))
+			access, err := ExtractRavelinAccess(filepath.Join(tempDir, "users", "john_doe.yml"), Settings{})
 			if err != nil {
 				t.Fatalf("error extracting user access: %v", err)
 			}
@@ -179,9 +180,96 @@ gsudo:
 				require.ErrorContains(t, err, tt.expError)
 			}
 
-			if diff := cmp.Diff(access.Gsudo, tt.expected); diff != "" {
+			// sources are covered by TestInheritGsudoAccess_Sources
+			if diff := cmp.Diff(access.Gsudo, tt.expected, cmpopts.IgnoreFields(GsudoAccess{}, "Sources", "AccessPoliciesSource")); diff != "" {
 				t.Errorf("expected ravelin access data (+) but got (-), %s", diff)
 			}
 		})
 	}
 }
+
+func TestServiceAccountGsudoAccess(t *testing.T) {
+	dir := createTempFiles(t, map[string][]byte{
+		"service-accounts/my-project/deployer.yml": []byte(`
+gcp:
+  groups:
+    - deployers
+gsudo:
+  inherit: true
+  escalations:
+    my-project:
+      - custom/deployer
+`),
+		"groups/deployers.yml": []byte(`
+gsudo:
+  escalations:
+    other-project:
+      - roles/run.developer
+`),
+	})
+
+	access, err := ExtractRavelinAccess(filepath.Join(dir, "service-accounts", "my-project", "deployer.yml"), Settings{})
+	require.NoError(t, err)
+	require.Equal(t, SERVICE, access.Type)
+	require.Equal(t, "deployer@my-project.iam.gserviceaccount.com", access.Email)
+
+	require.NoError(t, access.InheritGsudoAccess())
+	expected := map[string][]string{
+		"my-project":    {"projects/my-project/roles/deployer"},
+		"other-project": {"roles/run.developer"},
+	}
+	if diff := cmp.Diff(expected, access.Gsudo.Escalations); diff != "" {
+		t.Errorf("expected escalations (-) but got (+), %s", diff)
+	}
+
+	files, err := GetServiceAccountFiles(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join("my-project", "deployer.yml")}, files)
+}
+
+func TestInheritGsudoAccess_Sources(t *testing.T) {
+	dir := createTempFiles(t, map[string][]byte{
+		"users/john_doe.yml": []byte(`gcp:
+  groups:
+    - team
+gsudo:
+  inherit: true
+  escalations:
+    my-project:
+      - roles/owner
+`),
+		"groups/team.yml": []byte(`gcp:
+  groups:
+    - engineering
+gsudo:
+  access-policies: true
+  escalations:
+    my-project:
+      - roles/owner
+      - roles/viewer
+`),
+		"groups/engineering.yml": []byte(`gsudo:
+  access-policies: false
+  escalations:
+    my-project:
+      - roles/viewer
+`),
+	})
+
+	access, err := ExtractRavelinAccess(filepath.Join(dir, "users", "john_doe.yml"), Settings{})
+	require.NoError(t, err)
+	require.NoError(t, access.InheritGsudoAccess())
+
+	user := Source{File: filepath.Join(dir, "users", "john_doe.yml")}
+	team := Source{Group: "team", File: filepath.Join(dir, "groups", "team.yml")}
+	engineering := Source{Group: "engineering", File: filepath.Join(dir, "groups", "engineering.yml")}
+
+	require.Equal(t, map[string]map[string][]Source{
+		"my-project": {
+			"roles/owner":  {user, team},
+			"roles/viewer": {team, engineering},
+		},
+	}, access.Gsudo.Sources)
+	require.Equal(t, &team, access.Gsudo.AccessPoliciesSource)
+	require.Equal(t, "group:team", access.Gsudo.AccessPoliciesSource.String())
+}
@@ -3,8 +3,7 @@ package ravelinaccess
 import (
 	"fmt"
 	"strings"
-
-	"gopkg.in/yaml.v3"
+	"time"
 )
 
 // RavelinAccess can be assigned to an entity which can either be a user, a service
@@ -17,16 +16,60 @@ const (
 	SERVICE EntityType = 2
 )
 
+// Statuses of users, groups and service accounts. Only active entities are
+// granted access.
+const (
+	StatusActive     = "active"
+	StatusSuspended  = "suspended"
+	StatusOffboarded = "offboarded"
+)
+
+// Statuses lists the valid statuses.
+var Statuses = []string{StatusActive, StatusSuspended, StatusOffboarded}
+
+func (t EntityType) String() string {
+	switch t {
+	case USER:
+		return "user"
+	case GROUP:
+		return "group"
+	case SERVICE:
+		return "service_account"
+	}
+	return fmt.Sprintf("EntityType(%d)", int32(t))
+}
+
 // RavelinAccess represents the access configuration at Ravelin, it can describe
 // the access to multiple services and platforms for a user or a workspace group.
 type RavelinAccess struct {
-	// Email is the email of the user or group.
-	Email string
+	// Email is the email of the user or group. It is derived from the file path
+	// unless it is explicitly set in the file.
+	Email string `yaml:"email,omitempty"`
 	// Type indicates if the ravelin access is assigned to a user, a group or a service.
-	Type EntityType
-
-	// GCP represents the GCP IAM roles and groups for the user or group. For now it only supports
-	// the groups. Only users can be part of groups, groups cannot be part of other groups.
+	Type EntityType `yaml:"-"`
+	// Project is the GCP project of a service account. It defaults to the
+	// directory of the service account file, e.g. service-accounts/<project>/<name>.yml.
+	Project string `yaml:"project,omitempty"`
+
+	// DisplayName is the full name of the user or the name of the group.
+	DisplayName string `yaml:"display_name,omitempty"`
+	// Team is the team the user or group belongs to.
+	Team string `yaml:"team,omitempty"`
+	// Manager is the email of the user's manager.
+	Manager string `yaml:"manager,omitempty"`
+	// EmploymentType is the type of employment of the user, e.g. employee or contractor.
+	EmploymentType string `yaml:"employment_type,omitempty"`
+	// StartDate is the date the user started, in the YYYY-MM-DD format.
+	StartDate string `yaml:"start_date,omitempty"`
+	// Status is the status of the entity, one of Statuses, active if unset. The
+	// files of people who left are kept as an audit trail of their access, but
+	// only active entities are granted access.
+	Status string `yaml:"status,omitempty"`
+	// OffboardedAt is the date the user was offboarded, in the YYYY-MM-DD format.
+	OffboardedAt string `yaml:"offboarded_at,omitempty"`
+
+	// GCP represents the GCP IAM roles and groups for the user or group. Users, service
+	// accounts and groups can be part of groups and be granted project roles.
 	GCP GCPAccess `yaml:"gcp,omitempty"`
 	// Gsudo represents the gsudo configuration for the user or group.
 	Gsudo GsudoAccess `yaml:"gsudo,omitempty"`
@@ -35,15 +78,60 @@ type RavelinAccess struct {
 
 	// Keeping track of original file
 	filePath string
+	// settings are the naming rules used to derive emails from file paths.
+	settings Settings
+	// directory is the directory the entity was loaded from, if any. Groups are
+	// looked up in it rather than read from disk.
+	directory *Directory
+	// warnings about the entries of the file expiring soon.
+	warnings []string
+	// bundles are the role bundles of the IAM directory of the entity.
+	bundles roleBundles
+	// projects is the projects inventory of the IAM directory of the entity.
+	projects projectInventory
 }
 
 // GCPAccess represents the GCP IAM roles and groups for a user or a group.
 type GCPAccess struct {
-	// Groups is a list of google workspace groups the user belongs to.
+	// Groups is a list of google workspace groups the user or group belongs to.
 	Groups []string `yaml:"groups,omitempty"`
+	// GroupExpiries maps the groups with a membership expiry, set with the
+	// `{group: <name>, expires: <date>}` form of the groups entries, to their
+	// expiry. Expired memberships are dropped from the groups.
+	GroupExpiries map[string]time.Time `yaml:"-"`
+	// Roles is a map of project names to the standing roles granted to the
+	// user, service account or group on the project. Unlike gsudo escalations,
+	// roles of groups are bound to the group and not inherited by its members.
+	// Project selectors are expanded like the ones of escalations.
+	Roles map[string][]string `yaml:"roles,omitempty"`
+}
+
+// Active reports whether the entity is active and granted access.
+func (a *RavelinAccess) Active() bool {
+	return a.Status == "" || a.Status == StatusActive
 }
 
-func ExtractRavelinAccess(filePath string) (RavelinAccess, error) {
+// ExtractRavelinAccess reads the access configuration from the IAM YAML file,
+// the email of the entity is derived from the file path using the naming rules
+// of the settings. The projects inventory and the bundles of the IAM directory
+// are read again on every call, so it is meant for a single file: use
+// LoadDirectory, which reads them once, to parse the files of a directory.
+func ExtractRavelinAccess(filePath string, settings Settings) (RavelinAccess, error) {
+	projects, err := loadProjects(iamRoot(filePath))
+	if err != nil {
+		return RavelinAccess{}, err
+	}
+	bundles, err := loadBundles(iamRoot(filePath), projects)
+	if err != nil {
+		return RavelinAccess{}, err
+	}
+	return extractRavelinAccess(filePath, settings, bundles, projects)
+}
+
+// extractRavelinAccess reads the access configuration from the IAM YAML file,
+// the roles of the file referencing the bundles and its project keys selecting
+// projects of the inventory.
+func extractRavelinAccess(filePath string, settings Settings, bundles roleBundles, projects projectInventory) (RavelinAccess, error) {
 	data, err := readFile(filePath)
 	if err != nil {
 		return RavelinAccess{}, fmt.Errorf("error reading file: %w", err)
@@ -51,28 +139,36 @@ func ExtractRavelinAccess(filePath string) (RavelinAccess, error) {
 
 	var acc RavelinAccess
 	acc.filePath = filePath
+	acc.settings = settings
+	acc.bundles = bundles
+	acc.projects = projects
 
 	acc.Type, err = fileToType(filePath)
 	if err != nil {
 		return RavelinAccess{}, fmt.Errorf("error determining type of entity from file: %w", err)
 	}
 
-	acc.Email, err = fileToEmail(filePath, acc.Type)
-	if err != nil {
-		return RavelinAccess{}, fmt.Errorf("error determining email of file: %w", err)
+	// validation errors are returned as is, they already name the file
+	if err := acc.extractAccess(data); err != nil {
+		return RavelinAccess{}, err
 	}
 
-	err = acc.extractAccess(data)
-	if err != nil {
-		return RavelinAccess{}, fmt.Errorf("error extracting user access: %w", err)
+	// the email is derived once the file is parsed, as it can be set explicitly
+	// and the project of service accounts can be set in the file
+	if acc.Email == "" {
+		acc.Email, err = fileToEmail(filePath, acc.Type, settings, acc.Project)
+		if err != nil {
+			return RavelinAccess{}, fmt.Errorf("error determining email of file: %w", err)
+		}
 	}
 
 	return acc, nil
 }
 
 func (a *RavelinAccess) extractAccess(data []byte) error {
-	if err := yaml.Unmarshal(data, &a); err != nil {
-		return fmt.Errorf("error unmarshaling IAM file: %w", err)
+	expiries, requirements, err := decodeStrict(a.filePath, data, a, a.bundles, a.projects)
+	if err != nil {
+		return err
 	}
 
 	if a.Gsudo.Escalations == nil {
@@ -83,8 +179,13 @@ func (a *RavelinAccess) extractAccess(data []byte) error {
 		a.GCP.Groups = make([]string, 0)
 	}
 
-	// Ensure custom roles are transformed to full GCP role names
-	a.Gsudo.Escalations = expandCustomRoles(a.Gsudo.Escalations)
+	// Ensure project selectors are resolved first, so that bundles and custom
+	// roles are transformed to full GCP role names on each project
+	a.Gsudo.Escalations = expandCustomRoles(a.bundles.expandBundles(a.projects.expandProjects(a.Gsudo.Escalations)))
+	a.GCP.Roles = expandCustomRoles(a.bundles.expandBundles(a.projects.expandProjects(a.GCP.Roles)))
+
+	a.applyExpiries(expiries)
+	a.applyRequirements(requirements)
 
 	return nil
 }
@@ -95,10 +196,26 @@ func (a *RavelinAccess) extractAccess(data []byte) error {
 func expandCustomRoles(m map[string][]string) map[string][]string {
 	for project, roles := range m {
 		for i, role := range roles {
-			if strings.HasPrefix(role, "custom/") {
-				roles[i] = fmt.Sprintf("projects/%s/roles/%s", project, role[7:])
-			}
+			roles[i] = expandCustomRole(project, role)
 		}
 	}
 	return m
 }
+
+// expandRole returns the roles granted by the role on the project, the roles of
+// the bundle it references or the role itself, custom roles being expanded.
+func (a *RavelinAccess) expandRole(project, role string) []string {
+	roles := a.bundles.expand(project, []string{role})
+	for i, r := range roles {
+		roles[i] = expandCustomRole(project, r)
+	}
+	return roles
+}
+
+// expandCustomRole expands the role if it is a custom role in its short form.
+func expandCustomRole(project, role string) string {
+	if strings.HasPrefix(role, "custom/") {
+		return fmt.Sprintf("projects/%s/roles/%s", project, role[7:])
+	}
+	return role
+}
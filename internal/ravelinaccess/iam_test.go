@@ -97,13 +97,35 @@ gsudo:
 				},
 			},
 		},
+		{
+			name: "project_roles",
+			input: map[string][]byte{
+				"users/john_doe.yml": []byte(`
+gcp:
+  roles:
+    test-project:
+      - roles/viewer
+      - custom/auditor
+`)},
+			expected: RavelinAccess{
+				Email: "john.doe@ravelin.com",
+				Type:  USER,
+				GCP: GCPAccess{
+					Groups: []string{},
+					Roles:  map[string][]string{"test-project": {"roles/viewer", "projects/test-project/roles/auditor"}},
+				},
+				Gsudo: GsudoAccess{
+					Escalations: map[string][]string{},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			dir := createTempFiles(t, tt.input)
 
-			user, err := ExtractRavelinAccess(filepath.Join(dir, "users/john_doe.yml"))
+			user, err := ExtractRavelinAccess(filepath.Join(dir, "users/john_doe.yml"), Settings{})
 			if tt.expError == "" {
 				require.NoError(t, err)
 			}
@@ -119,6 +141,80 @@ gsudo:
 	}
 }
 
+func TestExtractIdentity(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		input    string
+		expected RavelinAccess
+		expError string
+	}{
+		{
+			name: "explicit_email_and_identity",
+			file: "users/jose_garcia_lopez.yml",
+			input: `
+email: pepe@ravelin.com
+display_name: José García López
+team: platform
+manager: jane.doe@ravelin.com
+employment_type: contractor
+start_date: 2024-03-01
+`,
+			expected: RavelinAccess{
+				Email:          "pepe@ravelin.com",
+				Type:           USER,
+				DisplayName:    "José García López",
+				Team:           "platform",
+				Manager:        "jane.doe@ravelin.com",
+				EmploymentType: "contractor",
+				StartDate:      "2024-03-01",
+				GCP:            GCPAccess{Groups: []string{}},
+				Gsudo:          GsudoAccess{Escalations: map[string][]string{}},
+			},
+		},
+		{
+			name:  "explicit_email_with_non_standard_file_name",
+			file:  "users/jean.claude_van_damme.yml",
+			input: "email: jcvd@ravelin.com\n",
+			expected: RavelinAccess{
+				Email: "jcvd@ravelin.com",
+				Type:  USER,
+				GCP:   GCPAccess{Groups: []string{}},
+				Gsudo: GsudoAccess{Escalations: map[string][]string{}},
+			},
+		},
+		{
+			name:     "invalid_email",
+			file:     "users/john_doe.yml",
+			input:    "email: john.doe\n",
+			expError: "invalid email",
+		},
+		{
+			name:     "invalid_start_date",
+			file:     "users/john_doe.yml",
+			input:    "start_date: 01/03/2024\n",
+			expError: "invalid start_date",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := createTempFiles(t, map[string][]byte{tt.file: []byte(tt.input)})
+
+			access, err := ExtractRavelinAccess(filepath.Join(dir, tt.file), Settings{})
+			if tt.expError != "" {
+				require.ErrorContains(t, err, tt.expError)
+				return
+			}
+			require.NoError(t, err)
+
+			if diff := cmp.Diff(tt.expected, access, cmpopts.IgnoreUnexported(RavelinAccess{})); diff != "" {
+				t.Errorf("expected ravelin access data (-) but got (+), %s", diff)
+			}
+		})
+	}
+}
+
 func TestExpandCustomRoles(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -153,3 +249,98 @@ func TestExpandCustomRoles(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractRavelinAccess_Validation(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		expErrors []string
+	}{
+		{
+			name: "unknown_fields",
+			input: `gsudo:
+  inherrit: true
+  escalation:
+    my-project:
+      - roles/owner
+`,
+			expErrors: []string{
+				`users/john_doe.yml:2:3: unknown field "inherrit" in ` + "`gsudo`",
+				`users/john_doe.yml:3:3: unknown field "escalation" in ` + "`gsudo`",
+			},
+		},
+		{
+			name: "wrong_types",
+			input: `gcp:
+  groups: platform
+gsudo:
+  inherit: "yes"
+`,
+			expErrors: []string{
+				"users/john_doe.yml:2:11: `gcp.groups` must be a list",
+				"users/john_doe.yml:4:12: `gsudo.inherit` must be a boolean, got \"yes\"",
+			},
+		},
+		{
+			name: "invalid_roles_and_projects",
+			input: `gsudo:
+  escalations:
+    My_Project:
+      - roles/owner
+    my-project:
+      - owner
+      - custom/myRole
+      - projects/my-project/roles/myRole
+`,
+			expErrors: []string{
+				`users/john_doe.yml:3:5: invalid project ID "My_Project" in gsudo escalations`,
+				`users/john_doe.yml:6:9: invalid role "owner" for project my-project`,
+			},
+		},
+		{
+			name: "invalid_status",
+			input: `status: left
+offboarded_at: 2026-09-30
+`,
+			expErrors: []string{
+				`users/john_doe.yml:1:9: invalid status "left", expected one of active, suspended, offboarded`,
+				"users/john_doe.yml:2:16: offboarded_at requires status offboarded",
+			},
+		},
+		{
+			name: "invalid_project_roles",
+			input: `gcp:
+  roles:
+    my-project:
+      - viewer
+`,
+			expErrors: []string{
+				`users/john_doe.yml:4:9: invalid role "viewer" for project my-project`,
+			},
+		},
+		{
+			name: "twingate_resources_without_groups",
+			input: `twingate:
+  enabled: true
+  resources:
+    - prod-db
+`,
+			expErrors: []string{
+				"users/john_doe.yml:4:5: `twingate.resources` requires `twingate.groups`",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := createTempFiles(t, map[string][]byte{"users/john_doe.yml": []byte(tt.input)})
+
+			_, err := ExtractRavelinAccess(filepath.Join(dir, "users/john_doe.yml"), Settings{})
+			errs := Errors(err)
+			require.Len(t, errs, len(tt.expErrors))
+			for i, expErr := range tt.expErrors {
+				require.ErrorContains(t, errs[i], expErr)
+			}
+		})
+	}
+}
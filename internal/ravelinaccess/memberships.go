@@ -0,0 +1,88 @@
+package ravelinaccess
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// GroupEmail returns the email of the group with the given name: the email of
+// its file, or the email derived from groups/<name>.yml if it has no file.
+func (d *Directory) GroupEmail(name string) (string, error) {
+	if group, ok := d.groups[name]; ok {
+		return group.Email, nil
+	}
+	return fileToEmail(filepath.Join(d.Path, "groups", name+yamlExtensions[0]), GROUP, d.Settings, "")
+}
+
+// MissingGroups returns warnings about the groups listed in the groups of an
+// entity which have no group file, sorted by group.
+func (d *Directory) MissingGroups() []string {
+	var warnings []string
+	for name, members := range d.members {
+		if _, ok := d.groups[name]; ok {
+			continue
+		}
+		files := make([]string, 0, len(members))
+		for _, member := range members {
+			files = append(files, member.filePath)
+		}
+		slices.Sort(files)
+		warnings = append(warnings, fmt.Sprintf("group %s has no group file, it is referenced in %s", name, strings.Join(files, ", ")))
+	}
+	slices.Sort(warnings)
+	return warnings
+}
+
+// Memberships returns the emails of the members of every group listed in the
+// groups of an entity, by group email. Members are the active users, service
+// accounts if includeServiceAccounts is set, and groups listing the group.
+// With transitive set, users and service accounts are members of the groups
+// they belong to through nested groups as well, and groups are not returned as
+// members. Members are sorted.
+func (d *Directory) Memberships(includeServiceAccounts, transitive bool) (map[string][]string, error) {
+	members := make(map[string][]string)
+	add := func(group, member string) error {
+		email, err := d.GroupEmail(group)
+		if err != nil {
+			return fmt.Errorf("error determining email of group %s: %w", group, err)
+		}
+		members[email] = append(members[email], member)
+		return nil
+	}
+
+	entities := slices.Clone(d.users)
+	if includeServiceAccounts {
+		entities = append(entities, d.serviceAccounts...)
+	}
+	if !transitive {
+		for _, name := range d.GroupNames() {
+			entities = append(entities, d.groups[name])
+		}
+	}
+
+	for _, entity := range entities {
+		if !entity.Active() {
+			continue
+		}
+		groups := entity.GCP.Groups
+		if transitive {
+			var err error
+			if groups, err = entity.AllGroups(); err != nil {
+				return nil, err
+			}
+		}
+		for _, group := range groups {
+			if err := add(group, entity.Email); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for email, emails := range members {
+		slices.Sort(emails)
+		members[email] = slices.Compact(emails)
+	}
+	return members, nil
+}
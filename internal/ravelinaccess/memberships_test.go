@@ -0,0 +1,67 @@
+package ravelinaccess
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberships(t *testing.T) {
+	dir := createTempFiles(t, map[string][]byte{
+		"users/john_doe.yml":                     []byte("gcp:\n  groups:\n    - team\n    - oncall\n"),
+		"users/jane_doe.yml":                     []byte("gcp:\n  groups:\n    - engineering\n"),
+		"groups/team.yml":                        []byte("gcp:\n  groups:\n    - engineering\n"),
+		"groups/engineering.yml":                 []byte("email: eng@ravelin.com\n"),
+		"service-accounts/my-project/ci-bot.yml": []byte("gcp:\n  groups:\n    - team\n"),
+	})
+
+	d, err := LoadDirectory(dir, Settings{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name                   string
+		includeServiceAccounts bool
+		transitive             bool
+		expected               map[string][]string
+	}{
+		{
+			name: "direct",
+			expected: map[string][]string{
+				"eng@ravelin.com":        {"gcp-team@ravelin.com", "jane.doe@ravelin.com"},
+				"gcp-oncall@ravelin.com": {"john.doe@ravelin.com"},
+				"gcp-team@ravelin.com":   {"john.doe@ravelin.com"},
+			},
+		},
+		{
+			name:                   "service_accounts",
+			includeServiceAccounts: true,
+			expected: map[string][]string{
+				"eng@ravelin.com":        {"gcp-team@ravelin.com", "jane.doe@ravelin.com"},
+				"gcp-oncall@ravelin.com": {"john.doe@ravelin.com"},
+				"gcp-team@ravelin.com":   {"ci-bot@my-project.iam.gserviceaccount.com", "john.doe@ravelin.com"},
+			},
+		},
+		{
+			name:       "transitive",
+			transitive: true,
+			expected: map[string][]string{
+				"eng@ravelin.com":        {"jane.doe@ravelin.com", "john.doe@ravelin.com"},
+				"gcp-oncall@ravelin.com": {"john.doe@ravelin.com"},
+				"gcp-team@ravelin.com":   {"john.doe@ravelin.com"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memberships, err := d.Memberships(tt.includeServiceAccounts, tt.transitive)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, memberships)
+		})
+	}
+
+	require.Equal(t, []string{
+		"group oncall has no group file, it is referenced in " + filepath.Join(dir, "users/john_doe.yml"),
+	}, d.MissingGroups())
+}
@@ -0,0 +1,439 @@
+package ravelinaccess
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// policiesFiles are the names of the policies file in the root of the IAM
+// directory.
+var policiesFiles = []string{"policies.yml", "policies.yaml"}
+
+// Policy modes. Violations of the rules of enforced policies fail the loading
+// of the IAM directory, the ones of audited policies are warnings.
+const (
+	PolicyModeEnforce = "enforce"
+	PolicyModeAudit   = "audit"
+)
+
+// ownerRole is the role counted by max_owners rules.
+const ownerRole = "roles/owner"
+
+// policies are the guardrails of the IAM directory, rules the access granted
+// by the IAM files must follow. Rules apply to the active entities only.
+type policies struct {
+	// Mode is either PolicyModeEnforce or PolicyModeAudit, defaults to
+	// PolicyModeEnforce.
+	Mode string `yaml:"mode,omitempty"`
+	// DenyRoles are roles nobody may hold on the projects of the rules.
+	DenyRoles []denyRolesRule `yaml:"deny_roles,omitempty"`
+	// MaxOwners limit the number of users and service accounts holding
+	// roles/owner on the projects of the rules.
+	MaxOwners []maxOwnersRule `yaml:"max_owners,omitempty"`
+	// GroupDeniedRoles are roles a group and its members may never hold.
+	GroupDeniedRoles []groupDeniedRolesRule `yaml:"group_denied_roles,omitempty"`
+	// MutuallyExclusiveRoles are sets of roles nobody may hold more than one of
+	// on the same project.
+	MutuallyExclusiveRoles []mutuallyExclusiveRolesRule `yaml:"mutually_exclusive_roles,omitempty"`
+
+	// projects is the projects inventory the projects of the rules select.
+	projects projectInventory
+}
+
+// Every rule is named, the name being reported with its violations. Projects is
+// a project ID or a project selector, the rule applying to every project when
+// it is empty. Roles are predefined roles, custom roles in their short form,
+// matching the custom role of every project, or project custom roles.
+type (
+	denyRolesRule struct {
+		Name     string   `yaml:"name"`
+		Projects string   `yaml:"projects,omitempty"`
+		Roles    []string `yaml:"roles"`
+	}
+	maxOwnersRule struct {
+		Name     string `yaml:"name"`
+		Projects string `yaml:"projects,omitempty"`
+		Max      int    `yaml:"max"`
+	}
+	groupDeniedRolesRule struct {
+		Name     string   `yaml:"name"`
+		Group    string   `yaml:"group"`
+		Projects string   `yaml:"projects,omitempty"`
+		Roles    []string `yaml:"roles"`
+	}
+	mutuallyExclusiveRolesRule struct {
+		Name     string   `yaml:"name"`
+		Projects string   `yaml:"projects,omitempty"`
+		Roles    []string `yaml:"roles"`
+	}
+)
+
+// PolicyViolation is a breach of a rule of the policies file.
+type PolicyViolation struct {
+	// Rule is the name of the rule.
+	Rule string
+	// Files are the IAM files granting the access breaching the rule, sorted.
+	Files []string
+	Msg   string
+}
+
+func (v *PolicyViolation) Error() string {
+	return fmt.Sprintf("policy %s violated: %s, granted in %s", v.Rule, v.Msg, strings.Join(v.Files, ", "))
+}
+
+// loadPolicies reads the policies file from the root of the IAM directory, nil
+// is returned if the IAM directory has none. The projects of the rules select
+// projects of the inventory and the groups of the rules must have a file.
+func loadPolicies(iamDirectory string, projects projectInventory, groups map[string]*RavelinAccess) (*policies, error) {
+	for _, name := range policiesFiles {
+		file := filepath.Join(iamDirectory, name)
+		if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		data, err := readFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, &ValidationError{File: file, Msg: err.Error()}
+		}
+		p := &policies{projects: projects}
+		if len(doc.Content) == 0 {
+			return p, nil
+		}
+
+		v := &validator{file: file, projects: projects}
+		root := doc.Content[0]
+		v.checkNode(root, reflect.TypeOf(*p), "")
+		v.checkPolicies(root, groups)
+		if err := v.err(); err != nil {
+			return nil, err
+		}
+
+		if err := doc.Decode(p); err != nil {
+			return nil, &ValidationError{File: file, Msg: err.Error()}
+		}
+		return p, nil
+	}
+	return nil, nil
+}
+
+// checkPolicies checks the mode and the rules of the policies file: rules must
+// have a unique name, valid projects and roles, and the fields of their kind.
+func (v *validator) checkPolicies(root *yaml.Node, groups map[string]*RavelinAccess) {
+	if n := lookup(root, "mode"); n != nil && n.Value != PolicyModeEnforce && n.Value != PolicyModeAudit {
+		v.errorf(n, "invalid mode %q, expected %s or %s", n.Value, PolicyModeEnforce, PolicyModeAudit)
+	}
+
+	names := make(map[string]bool)
+	for _, kind := range []string{"deny_roles", "max_owners", "group_denied_roles", "mutually_exclusive_roles"} {
+		rules := lookup(root, kind)
+		if rules == nil || rules.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, rule := range rules.Content {
+			if rule.Kind != yaml.MappingNode {
+				continue
+			}
+
+			name := lookup(rule, "name")
+			switch {
+			case name == nil || name.Value == "":
+				v.errorf(rule, "missing name of %s rule", kind)
+			case names[name.Value]:
+				v.errorf(name, "duplicate rule name %q", name.Value)
+			default:
+				names[name.Value] = true
+			}
+			if projects := lookup(rule, "projects"); projects != nil {
+				v.checkProject(projects, kind+" rule")
+			}
+
+			switch kind {
+			case "max_owners":
+				// values of the wrong type are already reported
+				if limit := lookup(rule, "max"); limit == nil {
+					v.errorf(rule, "max_owners rule requires a max")
+				} else if n, err := strconv.Atoi(limit.Value); err == nil && n < 1 {
+					v.errorf(limit, "max must be positive, got %d", n)
+				}
+				continue
+			case "group_denied_roles":
+				if group := lookup(rule, "group"); group == nil {
+					v.errorf(rule, "group_denied_roles rule requires a group")
+				} else if _, ok := groups[group.Value]; !ok {
+					v.errorf(group, "unknown group %q, expected a groups/%s.yml file", group.Value, group.Value)
+				}
+			}
+
+			roles := lookup(rule, "roles")
+			if roles == nil || roles.Kind != yaml.SequenceNode || len(roles.Content) == 0 {
+				v.errorf(rule, "%s rule requires roles", kind)
+				continue
+			}
+			if kind == "mutually_exclusive_roles" && len(roles.Content) < 2 {
+				v.errorf(roles, "mutually_exclusive_roles rule requires at least 2 roles")
+			}
+			for _, role := range roles.Content {
+				if !validRole(role.Value) {
+					v.errorf(role, "invalid role %q, expected roles/<role>, custom/<role> or projects/<project>/roles/<role>", role.Value)
+				}
+			}
+		}
+	}
+}
+
+// audit reports whether the violations of the policies are warnings.
+func (p *policies) audit() bool {
+	return p.Mode == PolicyModeAudit
+}
+
+// inScope reports whether the project is one of the projects of a rule.
+func (p *policies) inScope(projects, project string) bool {
+	return projects == "" || slices.Contains(p.projects.resolve(projects), project)
+}
+
+// matchRoles reports whether one of the roles of a rule matches the role held
+// on the project.
+func matchRoles(roles []string, project, role string) bool {
+	return slices.ContainsFunc(roles, func(r string) bool { return expandCustomRole(project, r) == role })
+}
+
+// holder is an active entity and the roles it holds on each project, mapped
+// to the files granting them.
+type holder struct {
+	entity *RavelinAccess
+	roles  map[string]map[string][]string
+}
+
+// add records the role held on the project, granted in the file.
+func (h *holder) add(project, role, file string) {
+	if h.roles[project] == nil {
+		h.roles[project] = make(map[string][]string)
+	}
+	if !slices.Contains(h.roles[project][role], file) {
+		h.roles[project][role] = append(h.roles[project][role], file)
+	}
+}
+
+// holders returns the roles held by the active users, service accounts and
+// groups of the directory, in that order. Users and service accounts hold
+// their escalations, inherited ones included, their project roles and the
+// project roles of the groups they belong to. Groups hold the escalations and
+// project roles of their file.
+func (d *Directory) holders() ([]*holder, error) {
+	var holders []*holder
+	for _, entity := range slices.Concat(d.users, d.serviceAccounts) {
+		if !entity.Active() {
+			continue
+		}
+		h := &holder{entity: entity, roles: make(map[string]map[string][]string)}
+
+		access := entity.clone()
+		if err := access.InheritGsudoAccess(); err != nil {
+			return nil, err
+		}
+		for project, roles := range access.Gsudo.Sources {
+			for role, sources := range roles {
+				for _, source := range sources {
+					h.add(project, role, source.File)
+				}
+			}
+		}
+
+		closure, err := entity.groupClosure(entity.GCP.Groups)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving groups of %s: %w", entity.Email, err)
+		}
+		for _, granting := range append([]*RavelinAccess{entity}, closure...) {
+			for project, roles := range granting.GCP.Roles {
+				for _, role := range roles {
+					h.add(project, role, granting.filePath)
+				}
+			}
+		}
+		holders = append(holders, h)
+	}
+
+	for _, name := range d.GroupNames() {
+		group := d.groups[name]
+		if !group.Active() {
+			continue
+		}
+		h := &holder{entity: group, roles: make(map[string]map[string][]string)}
+		for _, m := range []map[string][]string{group.Gsudo.Escalations, group.GCP.Roles} {
+			for project, roles := range m {
+				for _, role := range roles {
+					h.add(project, role, group.filePath)
+				}
+			}
+		}
+		holders = append(holders, h)
+	}
+	return holders, nil
+}
+
+// breach collects the holders and files of a violation.
+type breach struct {
+	holders []string
+	files   []string
+}
+
+func (b *breach) add(email string, files []string) {
+	b.holders = append(b.holders, email)
+	b.files = append(b.files, files...)
+}
+
+// violation returns the violation of the rule, the message being formatted with
+// the args followed by the holders.
+func (b *breach) violation(rule, format string, args ...any) *PolicyViolation {
+	slices.Sort(b.files)
+	return &PolicyViolation{
+		Rule:  rule,
+		Files: slices.Compact(b.files),
+		Msg:   fmt.Sprintf(format, append(args, strings.Join(b.holders, ", "))...),
+	}
+}
+
+// breaches groups the breaches of a rule by project and role, so that a role
+// granted by a group to its members is reported once.
+type breaches map[[2]string]*breach
+
+func (b breaches) add(project, role, email string, files []string) {
+	key := [2]string{project, role}
+	if b[key] == nil {
+		b[key] = &breach{}
+	}
+	b[key].add(email, files)
+}
+
+// sortedKeys returns the projects and roles of the breaches, sorted.
+func (b breaches) sortedKeys() [][2]string {
+	keys := make([][2]string, 0, len(b))
+	for key := range b {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(x, y [2]string) int {
+		if c := strings.Compare(x[0], y[0]); c != 0 {
+			return c
+		}
+		return strings.Compare(x[1], y[1])
+	})
+	return keys
+}
+
+// evaluate returns the violations of the rules of the policies by the active
+// entities of the directory, sorted by rule kind, rule, project and role.
+func (p *policies) evaluate(d *Directory) ([]*PolicyViolation, error) {
+	if p == nil {
+		return nil, nil
+	}
+	holders, err := d.holders()
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []*PolicyViolation
+	for _, rule := range p.DenyRoles {
+		found := make(breaches)
+		for _, h := range holders {
+			for project, roles := range h.roles {
+				for role, files := range roles {
+					if p.inScope(rule.Projects, project) && matchRoles(rule.Roles, project, role) {
+						found.add(project, role, h.entity.Email, files)
+					}
+				}
+			}
+		}
+		for _, key := range found.sortedKeys() {
+			violations = append(violations, found[key].violation(rule.Name, "%s is denied on %s, held by %s", key[1], key[0]))
+		}
+	}
+
+	for _, rule := range p.MaxOwners {
+		found := make(breaches)
+		for _, h := range holders {
+			if h.entity.Type == GROUP {
+				continue // the members of groups are counted instead
+			}
+			for project, roles := range h.roles {
+				if files, ok := roles[ownerRole]; ok && p.inScope(rule.Projects, project) {
+					found.add(project, ownerRole, h.entity.Email, files)
+				}
+			}
+		}
+		for _, key := range found.sortedKeys() {
+			if b := found[key]; len(b.holders) > rule.Max {
+				violations = append(violations, b.violation(rule.Name, "%s has %d owners, more than %d: %s", key[0], len(b.holders), rule.Max))
+			}
+		}
+	}
+
+	for _, rule := range p.GroupDeniedRoles {
+		found := make(breaches)
+		for _, h := range holders {
+			if h.entity.Type == GROUP {
+				if groupName(h.entity.filePath) != rule.Group {
+					continue
+				}
+			} else {
+				groups, err := h.entity.AllGroups()
+				if err != nil {
+					return nil, err
+				}
+				if !slices.Contains(groups, rule.Group) {
+					continue
+				}
+			}
+			for project, roles := range h.roles {
+				for role, files := range roles {
+					if p.inScope(rule.Projects, project) && matchRoles(rule.Roles, project, role) {
+						found.add(project, role, h.entity.Email, files)
+					}
+				}
+			}
+		}
+		for _, key := range found.sortedKeys() {
+			violations = append(violations, found[key].violation(rule.Name, "group %s and its members may never hold %s on %s, held by %s", rule.Group, key[1], key[0]))
+		}
+	}
+
+	for _, rule := range p.MutuallyExclusiveRoles {
+		for _, h := range holders {
+			projects := make([]string, 0, len(h.roles))
+			for project := range h.roles {
+				projects = append(projects, project)
+			}
+			slices.Sort(projects)
+			for _, project := range projects {
+				if !p.inScope(rule.Projects, project) {
+					continue
+				}
+				b := &breach{}
+				var held []string
+				for role, files := range h.roles[project] {
+					if matchRoles(rule.Roles, project, role) {
+						held = append(held, role)
+						b.files = append(b.files, files...)
+					}
+				}
+				if len(held) > 1 {
+					slices.Sort(held)
+					b.holders = []string{h.entity.Email}
+					violations = append(violations, b.violation(rule.Name, "%s are mutually exclusive on %s, held together by %s", strings.Join(held, " and "), project))
+				}
+			}
+		}
+	}
+	return violations, nil
+}
@@ -0,0 +1,139 @@
+package ravelinaccess
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicies(t *testing.T) {
+	files := map[string][]byte{
+		"projects.yml": []byte(testProjects),
+		"users/john_doe.yml": []byte(`gcp:
+  groups:
+    - oncall
+gsudo:
+  inherit: true
+  escalations:
+    data-staging:
+      - custom/deployer
+      - custom/approver
+`),
+		"users/jane_doe.yml": []byte(`gcp:
+  groups:
+    - contractors
+  roles:
+    web-staging:
+      - roles/owner
+`),
+		"users/jim_doe.yml": []byte(`gsudo:
+  escalations:
+    data-eu-prod:
+      - roles/owner
+`),
+		"users/old_timer.yml": []byte(`status: offboarded
+gsudo:
+  escalations:
+    data-eu-prod:
+      - roles/owner
+`),
+		"groups/oncall.yml": []byte(`gsudo:
+  escalations:
+    env:prod:
+      - roles/owner
+`),
+		"groups/contractors.yml": []byte(`gcp:
+  roles:
+    data-staging:
+      - roles/viewer
+`),
+		"policies.yml": []byte(`deny_roles:
+  - name: no-owner-in-prod
+    projects: env:prod
+    roles:
+      - roles/owner
+max_owners:
+  - name: few-owners
+    max: 1
+group_denied_roles:
+  - name: contractors-not-owners
+    group: contractors
+    roles:
+      - roles/owner
+mutually_exclusive_roles:
+  - name: deploy-or-approve
+    roles:
+      - custom/deployer
+      - custom/approver
+`),
+	}
+	dir := createTempFiles(t, files)
+	path := func(file string) string { return filepath.Join(dir, file) }
+
+	_, err := LoadDirectory(dir, Settings{})
+	require.Equal(t, []string{
+		"policy no-owner-in-prod violated: roles/owner is denied on data-eu-prod, held by jim.doe@ravelin.com, john.doe@ravelin.com, gcp-oncall@ravelin.com, granted in " + path("groups/oncall.yml") + ", " + path("users/jim_doe.yml"),
+		"policy no-owner-in-prod violated: roles/owner is denied on data-us-prod, held by john.doe@ravelin.com, gcp-oncall@ravelin.com, granted in " + path("groups/oncall.yml"),
+		"policy few-owners violated: data-eu-prod has 2 owners, more than 1: jim.doe@ravelin.com, john.doe@ravelin.com, granted in " + path("groups/oncall.yml") + ", " + path("users/jim_doe.yml"),
+		"policy contractors-not-owners violated: group contractors and its members may never hold roles/owner on web-staging, held by jane.doe@ravelin.com, granted in " + path("users/jane_doe.yml"),
+		"policy deploy-or-approve violated: projects/data-staging/roles/approver and projects/data-staging/roles/deployer are mutually exclusive on data-staging, held together by john.doe@ravelin.com, granted in " + path("users/john_doe.yml"),
+	}, errorStrings(Errors(err)))
+
+	// in audit mode, violations are reported without failing the loading
+	files["policies.yml"] = []byte("mode: audit\nmax_owners:\n  - name: few-owners\n    projects: data-eu-prod\n    max: 1\n")
+	dir = createTempFiles(t, files)
+	d, err := LoadDirectory(dir, Settings{})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"policy few-owners violated: data-eu-prod has 2 owners, more than 1: jim.doe@ravelin.com, john.doe@ravelin.com, granted in " + filepath.Join(dir, "groups/oncall.yml") + ", " + filepath.Join(dir, "users/jim_doe.yml"),
+	}, d.PolicyViolations())
+}
+
+func TestPolicies_Invalid(t *testing.T) {
+	dir := createTempFiles(t, map[string][]byte{
+		"groups/oncall.yml":  []byte("gsudo:\n  inherit: false\n"),
+		"users/john_doe.yml": []byte("gsudo:\n  inherit: true\n"),
+		"policies.yml": []byte(`mode: warn
+deny_roles:
+  - name: no-owner
+    projects: env:prod
+    roles:
+      - owner
+  - name: no-owner
+    roles: []
+max_owners:
+  - name: few-owners
+    max: 0
+group_denied_roles:
+  - name: contractors
+    group: contractors
+    roles:
+      - roles/owner
+mutually_exclusive_roles:
+  - roles:
+      - roles/owner
+`),
+	})
+
+	_, err := LoadDirectory(dir, Settings{})
+	require.Equal(t, []string{
+		filepath.Join(dir, "policies.yml") + `:1:7: invalid mode "warn", expected enforce or audit`,
+		filepath.Join(dir, "policies.yml") + `:4:15: project selector "env:prod" in deny_roles rule requires a projects.yml inventory`,
+		filepath.Join(dir, "policies.yml") + `:6:9: invalid role "owner", expected roles/<role>, custom/<role> or projects/<project>/roles/<role>`,
+		filepath.Join(dir, "policies.yml") + `:7:11: duplicate rule name "no-owner"`,
+		filepath.Join(dir, "policies.yml") + `:7:5: deny_roles rule requires roles`,
+		filepath.Join(dir, "policies.yml") + `:11:10: max must be positive, got 0`,
+		filepath.Join(dir, "policies.yml") + `:14:12: unknown group "contractors", expected a groups/contractors.yml file`,
+		filepath.Join(dir, "policies.yml") + `:18:5: missing name of mutually_exclusive_roles rule`,
+		filepath.Join(dir, "policies.yml") + `:19:7: mutually_exclusive_roles rule requires at least 2 roles`,
+	}, errorStrings(Errors(err)))
+}
+
+func errorStrings(errs []error) []string {
+	s := make([]string, len(errs))
+	for i, err := range errs {
+		s[i] = err.Error()
+	}
+	return s
+}
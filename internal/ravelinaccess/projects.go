@@ -0,0 +1,185 @@
+package ravelinaccess
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// projectsFiles are the names of the projects inventory in the root of the IAM
+// directory.
+var projectsFiles = []string{"projects.yml", "projects.yaml"}
+
+// projectAttributes are the attributes of a project of the projects inventory,
+// which project selectors match.
+type projectAttributes struct {
+	// Environment is the environment of the project, e.g. `staging`.
+	Environment string `yaml:"environment,omitempty"`
+	// Team is the team owning the project.
+	Team string `yaml:"team,omitempty"`
+	// Labels are free-form attributes of the project.
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+// projectInventory maps the project IDs of the projects inventory to their
+// attributes. A nil inventory means the IAM directory has no inventory, project
+// IDs are then not checked and selectors can't be used.
+type projectInventory map[string]projectAttributes
+
+// loadProjects reads the projects inventory from the root of the IAM directory.
+// The inventory is optional, nil is returned if the IAM directory has none.
+func loadProjects(iamDirectory string) (projectInventory, error) {
+	for _, name := range projectsFiles {
+		file := filepath.Join(iamDirectory, name)
+		if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		data, err := readFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, &ValidationError{File: file, Msg: err.Error()}
+		}
+		projects := make(projectInventory)
+		if len(doc.Content) == 0 {
+			return projects, nil
+		}
+
+		v := &validator{file: file}
+		root := doc.Content[0]
+		v.checkNode(root, reflect.TypeOf(projects), "")
+		if root.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(root.Content); i += 2 {
+				if id := root.Content[i]; !projectIDRegex.MatchString(id.Value) {
+					v.errorf(id, "invalid project ID %q", id.Value)
+				}
+			}
+		}
+		if err := v.err(); err != nil {
+			// the valid part of the inventory is still returned, so that the
+			// project keys of the other files can be checked
+			_ = doc.Decode(&projects)
+			return projects, err
+		}
+
+		if err := doc.Decode(&projects); err != nil {
+			return nil, &ValidationError{File: file, Msg: err.Error()}
+		}
+		return projects, nil
+	}
+	return nil, nil
+}
+
+// isProjectSelector reports whether the project key of an escalation or project
+// roles map selects projects of the inventory rather than naming a project.
+// Project IDs can't contain any of the characters of selectors.
+func isProjectSelector(key string) bool {
+	return strings.ContainsAny(key, ":*?[,")
+}
+
+// projectSelector matches projects on all of its terms, comma separated: globs
+// on the project ID, e.g. `data-*-prod`, `env:<environment>`, `team:<team>` and
+// `label:<key>=<value>`.
+type projectSelector []func(id string, p projectAttributes) bool
+
+// parseProjectSelector parses the selector, see projectSelector.
+func parseProjectSelector(key string) (projectSelector, error) {
+	var selector projectSelector
+	for _, term := range strings.Split(key, ",") {
+		kind, value, ok := strings.Cut(term, ":")
+		if !ok {
+			if _, err := path.Match(term, ""); err != nil || term == "" {
+				return nil, fmt.Errorf("invalid glob %q", term)
+			}
+			selector = append(selector, func(id string, _ projectAttributes) bool {
+				matched, _ := path.Match(term, id)
+				return matched
+			})
+			continue
+		}
+		if value == "" {
+			return nil, fmt.Errorf("missing value of %s", kind)
+		}
+
+		switch kind {
+		case "env":
+			selector = append(selector, func(_ string, p projectAttributes) bool { return p.Environment == value })
+		case "team":
+			selector = append(selector, func(_ string, p projectAttributes) bool { return p.Team == value })
+		case "label":
+			label, labelValue, ok := strings.Cut(value, "=")
+			if !ok || label == "" {
+				return nil, fmt.Errorf("invalid label %q, expected <key>=<value>", value)
+			}
+			selector = append(selector, func(_ string, p projectAttributes) bool {
+				v, ok := p.Labels[label]
+				return ok && v == labelValue
+			})
+		default:
+			return nil, fmt.Errorf("unknown selector %s", kind)
+		}
+	}
+	return selector, nil
+}
+
+// match returns the IDs of the projects of the inventory matching the
+// selector, sorted.
+func (s projectSelector) match(projects projectInventory) []string {
+	var ids []string
+	for id, p := range projects {
+		if !slices.ContainsFunc(s, func(term func(string, projectAttributes) bool) bool { return !term(id, p) }) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// resolve returns the projects the project key of an escalation or project
+// roles map stands for, the project itself unless the key is a selector.
+// Invalid selectors resolve to no project, they are reported when the file is
+// validated.
+func (p projectInventory) resolve(key string) []string {
+	if !isProjectSelector(key) {
+		return []string{key}
+	}
+	selector, err := parseProjectSelector(key)
+	if err != nil {
+		return nil
+	}
+	return selector.match(p)
+}
+
+// expandProjects replaces the selectors of the map of projects to roles by the
+// projects they select. The roles of projects selected by several keys are
+// merged, roles granted several times are only kept once.
+func (p projectInventory) expandProjects(m map[string][]string) map[string][]string {
+	keys := slices.Sorted(maps.Keys(m))
+	if !slices.ContainsFunc(keys, isProjectSelector) {
+		return m
+	}
+
+	// keys are sorted so that the order of merged roles is stable
+	expanded := make(map[string][]string, len(m))
+	for _, key := range keys {
+		for _, project := range p.resolve(key) {
+			for _, role := range m[key] {
+				if !slices.Contains(expanded[project], role) {
+					expanded[project] = append(expanded[project], role)
+				}
+			}
+		}
+	}
+	return expanded
+}
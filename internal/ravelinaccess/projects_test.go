@@ -0,0 +1,144 @@
+package ravelinaccess
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testProjects = `data-eu-prod:
+  environment: prod
+  team: data
+  labels:
+    tier: critical
+data-us-prod:
+  environment: prod
+  team: data
+data-staging:
+  environment: staging
+  team: data
+web-staging:
+  environment: staging
+  team: web
+  labels:
+    tier: critical
+`
+
+func TestProjects(t *testing.T) {
+	setNow(t, date("2026-11-20"))
+
+	dir := createTempFiles(t, map[string][]byte{
+		"projects.yml": []byte(testProjects),
+		"bundles/oncall.yml": []byte(`roles:
+  - custom/reader
+projects:
+  team:web:
+    - roles/run.admin
+`),
+		"users/john_doe.yml": []byte(`gcp:
+  roles:
+    label:tier=critical:
+      - roles/viewer
+gsudo:
+  escalations:
+    env:staging:
+      - custom/deployer
+      - bundle:oncall
+    data-*-prod:
+      - roles/bigquery.admin
+    data-eu-prod:
+      - roles/owner
+      - roles/bigquery.admin
+    env:prod,team:data,label:tier=critical:
+      - role: roles/editor
+        expires: 2026-12-31
+`),
+	})
+
+	d, err := LoadDirectory(dir, Settings{})
+	require.NoError(t, err)
+	user, ok := d.ByEmail("john.doe@ravelin.com")
+	require.True(t, ok)
+
+	require.Equal(t, map[string][]string{
+		"data-eu-prod": {"roles/viewer"},
+		"web-staging":  {"roles/viewer"},
+	}, user.GCP.Roles)
+	require.Equal(t, map[string][]string{
+		"data-eu-prod": {"roles/bigquery.admin", "roles/owner", "roles/editor"},
+		"data-us-prod": {"roles/bigquery.admin"},
+		"data-staging": {"projects/data-staging/roles/deployer", "projects/data-staging/roles/reader"},
+		"web-staging":  {"projects/web-staging/roles/deployer", "projects/web-staging/roles/reader", "roles/run.admin"},
+	}, user.Gsudo.Escalations)
+	require.Equal(t, map[string]map[string]time.Time{
+		"data-eu-prod": {"roles/editor": date("2026-12-31")},
+	}, user.Gsudo.Expiries)
+}
+
+func TestProjects_Invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string][]byte
+		expErrors []string
+	}{
+		{
+			name: "unknown_projects",
+			files: map[string][]byte{
+				"projects.yml": []byte(testProjects),
+				"users/john_doe.yml": []byte(`gsudo:
+  escalations:
+    data-eu-prod:
+      - roles/owner
+    data-ap-prod:
+      - roles/owner
+    env:dev:
+      - roles/owner
+    team:data,label:tier:
+      - roles/owner
+    region:eu:
+      - roles/owner
+`),
+			},
+			expErrors: []string{
+				`users/john_doe.yml:5:5: unknown project "data-ap-prod" in gsudo escalations, expected a project of projects.yml`,
+				`users/john_doe.yml:7:5: project selector "env:dev" in gsudo escalations matches no project of projects.yml`,
+				`users/john_doe.yml:9:5: invalid project selector "team:data,label:tier" in gsudo escalations: invalid label "tier", expected <key>=<value>`,
+				`users/john_doe.yml:11:5: invalid project selector "region:eu" in gsudo escalations: unknown selector region`,
+			},
+		},
+		{
+			name: "selector_without_inventory",
+			files: map[string][]byte{
+				"users/john_doe.yml": []byte("gcp:\n  roles:\n    env:staging:\n      - roles/viewer\n"),
+			},
+			expErrors: []string{
+				`users/john_doe.yml:3:5: project selector "env:staging" in gcp roles requires a projects.yml inventory`,
+			},
+		},
+		{
+			name: "invalid_inventory",
+			files: map[string][]byte{
+				"projects.yml":       []byte("My_Project:\n  environment: prod\nmy-project:\n  env: prod\n"),
+				"users/john_doe.yml": []byte("gsudo:\n  inherit: true\n"),
+			},
+			expErrors: []string{
+				`projects.yml:4:3: unknown field "env" in ` + "`my-project`",
+				`projects.yml:1:1: invalid project ID "My_Project"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := createTempFiles(t, tt.files)
+
+			_, err := LoadDirectory(dir, Settings{})
+			errs := Errors(err)
+			require.Len(t, errs, len(tt.expErrors))
+			for i, expErr := range tt.expErrors {
+				require.ErrorContains(t, errs[i], expErr)
+			}
+		})
+	}
+}
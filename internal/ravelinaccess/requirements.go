@@ -0,0 +1,142 @@
+package ravelinaccess
+
+import (
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EscalationRequirements are the conditions of the escalations to the roles of
+// a project, set with the object form of the escalations of a project:
+//
+//	escalations:
+//	  prod-data:
+//	    roles:
+//	      - roles/owner
+//	    max_duration: 4h
+//	    requires_justification: true
+//	    approvers:
+//	      - platform
+//	      - jane.doe@ravelin.com
+type EscalationRequirements struct {
+	// MaxDuration is the longest an escalation may last, zero if it isn't
+	// limited.
+	MaxDuration time.Duration
+	// RequiresJustification is set if escalating requires a justification,
+	// e.g. a ticket.
+	RequiresJustification bool
+	// Approvers are the groups, by name, and the emails of the users who must
+	// approve the escalations, sorted.
+	Approvers []string
+}
+
+// merge returns the most restrictive combination of the requirements: the
+// shortest max duration, a justification if either requires one and the
+// approvers of both.
+func (r EscalationRequirements) merge(other EscalationRequirements) EscalationRequirements {
+	if other.MaxDuration != 0 && (r.MaxDuration == 0 || other.MaxDuration < r.MaxDuration) {
+		r.MaxDuration = other.MaxDuration
+	}
+	r.RequiresJustification = r.RequiresJustification || other.RequiresJustification
+	approvers := slices.Concat(r.Approvers, other.Approvers)
+	slices.Sort(approvers)
+	r.Approvers = slices.Compact(approvers)
+	return r
+}
+
+// requirement is the requirements of the escalations of a project key of an
+// IAM file, which can be a project selector.
+type requirement struct {
+	project string
+	EscalationRequirements
+}
+
+// extractRequirements collects the requirements of the escalations in their
+// object form, e.g. `{roles: [roles/owner], max_duration: 4h}`, which are
+// replaced by their roles so that the file decodes into lists of strings.
+func (v *validator) extractRequirements(root *yaml.Node) []requirement {
+	escalations := lookup(root, "gsudo", "escalations")
+	if escalations == nil || escalations.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var requirements []requirement
+	for i := 0; i+1 < len(escalations.Content); i += 2 {
+		project, n := escalations.Content[i], escalations.Content[i+1]
+		if n.Kind != yaml.MappingNode {
+			continue
+		}
+
+		// problems are reported and the entry replaced by an empty list, so the
+		// rest of the file is still validated
+		r := requirement{project: project.Value}
+		roles := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: n.Line, Column: n.Column}
+		hasRoles := false
+		for j := 0; j+1 < len(n.Content); j += 2 {
+			key, value := n.Content[j], n.Content[j+1]
+			switch key.Value {
+			case "roles":
+				hasRoles = true
+				if value.Kind != yaml.SequenceNode || len(value.Content) == 0 {
+					v.errorf(value, "roles of the escalations to %s must be a non-empty list", project.Value)
+					continue
+				}
+				roles = value
+			case "max_duration":
+				d, err := time.ParseDuration(value.Value)
+				if value.Kind != yaml.ScalarNode || err != nil || d <= 0 {
+					v.errorf(value, "invalid max_duration %q, expected a positive duration such as 4h or 30m", value.Value)
+					continue
+				}
+				r.MaxDuration = d
+			case "requires_justification":
+				if value.Kind != yaml.ScalarNode || value.ShortTag() != "!!bool" {
+					v.errorf(value, "requires_justification must be a boolean, got %q", value.Value)
+					continue
+				}
+				_ = value.Decode(&r.RequiresJustification)
+			case "approvers":
+				if value.Kind != yaml.SequenceNode {
+					v.errorf(value, "approvers must be a list of groups or emails")
+					continue
+				}
+				for _, approver := range value.Content {
+					if approver.Kind != yaml.ScalarNode || approver.Value == "" {
+						v.errorf(approver, "approvers must be a list of groups or emails")
+						continue
+					}
+					r.Approvers = append(r.Approvers, approver.Value)
+				}
+			default:
+				v.errorf(key, "unknown field %q, expected roles, max_duration, requires_justification and approvers", key.Value)
+			}
+		}
+		if !hasRoles {
+			v.errorf(n, "missing roles of the escalations to %s", project.Value)
+		}
+
+		escalations.Content[i+1] = roles
+		slices.Sort(r.Approvers)
+		r.Approvers = slices.Compact(r.Approvers)
+		requirements = append(requirements, r)
+	}
+	return requirements
+}
+
+// applyRequirements records the requirements of the projects the project keys
+// of the requirements resolve to, requirements of a project set by several
+// keys being merged. Projects whose roles all expired are skipped.
+func (a *RavelinAccess) applyRequirements(requirements []requirement) {
+	for _, r := range requirements {
+		for _, project := range a.projects.resolve(r.project) {
+			if _, ok := a.Gsudo.Escalations[project]; !ok {
+				continue
+			}
+			if a.Gsudo.Requirements == nil {
+				a.Gsudo.Requirements = make(map[string]EscalationRequirements)
+			}
+			a.Gsudo.Requirements[project] = a.Gsudo.Requirements[project].merge(r.EscalationRequirements)
+		}
+	}
+}
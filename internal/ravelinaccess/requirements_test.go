@@ -0,0 +1,91 @@
+package ravelinaccess
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequirements(t *testing.T) {
+	dir := createTempFiles(t, map[string][]byte{
+		"users/john_doe.yml": []byte(`gcp:
+  groups:
+    - oncall
+gsudo:
+  inherit: true
+  escalations:
+    my-project:
+      roles:
+        - roles/viewer
+        - custom/deployer
+      max_duration: 8h
+      approvers:
+        - jane.doe@ravelin.com
+    other-project:
+      - roles/viewer
+`),
+		"groups/oncall.yml": []byte(`gsudo:
+  escalations:
+    my-project:
+      roles:
+        - role: roles/owner
+          expires: 2099-01-01
+      max_duration: 1h
+      requires_justification: true
+      approvers:
+        - platform
+        - jane.doe@ravelin.com
+`),
+	})
+
+	d, err := LoadDirectory(dir, Settings{})
+	require.NoError(t, err)
+	user, ok := d.ByEmail("john.doe@ravelin.com")
+	require.True(t, ok)
+
+	// the object form lists the roles of the project like the list form
+	require.Equal(t, map[string][]string{
+		"my-project":    {"roles/viewer", "projects/my-project/roles/deployer"},
+		"other-project": {"roles/viewer"},
+	}, user.Gsudo.Escalations)
+	require.Equal(t, map[string]EscalationRequirements{
+		"my-project": {MaxDuration: 8 * time.Hour, Approvers: []string{"jane.doe@ravelin.com"}},
+	}, user.Gsudo.Requirements)
+
+	// the most restrictive requirements win through inheritance
+	require.NoError(t, user.InheritGsudoAccess())
+	require.Equal(t, []string{"projects/my-project/roles/deployer", "roles/owner", "roles/viewer"}, user.Gsudo.Escalations["my-project"])
+	require.Equal(t, map[string]EscalationRequirements{
+		"my-project": {MaxDuration: time.Hour, RequiresJustification: true, Approvers: []string{"jane.doe@ravelin.com", "platform"}},
+	}, user.Gsudo.Requirements)
+}
+
+func TestRequirements_Invalid(t *testing.T) {
+	dir := createTempFiles(t, map[string][]byte{"users/john_doe.yml": []byte(`gsudo:
+  escalations:
+    my-project:
+      max_duration: forever
+      requires_justification: "yes"
+      approvers: platform
+      approval: true
+    other-project:
+      roles: []
+`)})
+
+	_, err := ExtractRavelinAccess(filepath.Join(dir, "users/john_doe.yml"), Settings{})
+	errs := Errors(err)
+	expErrors := []string{
+		`users/john_doe.yml:4:21: invalid max_duration "forever", expected a positive duration such as 4h or 30m`,
+		`users/john_doe.yml:5:31: requires_justification must be a boolean, got "yes"`,
+		`users/john_doe.yml:6:18: approvers must be a list of groups or emails`,
+		`users/john_doe.yml:7:7: unknown field "approval", expected roles, max_duration, requires_justification and approvers`,
+		`users/john_doe.yml:4:7: missing roles of the escalations to my-project`,
+		`users/john_doe.yml:9:14: roles of the escalations to other-project must be a non-empty list`,
+	}
+	require.Len(t, errs, len(expErrors))
+	for i, expErr := range expErrors {
+		require.ErrorContains(t, errs[i], expErr)
+	}
+}
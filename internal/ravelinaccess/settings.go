@@ -0,0 +1,236 @@
+package ravelinaccess
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Default naming rules, they match the Ravelin IAM directory.
+const (
+	DefaultUserDomain    = "ravelin.com"
+	DefaultGroupPrefix   = "gcp-"
+	DefaultUserSeparator = "."
+)
+
+// settingsFiles are the names of the settings file in the root of the IAM
+// directory.
+var settingsFiles = []string{"config.yml", "config.yaml"}
+
+// Settings are the naming rules used to derive emails from the paths of the
+// IAM YAML files. Unset fields fall back to the defaults.
+type Settings struct {
+	// UserDomain is the email domain of users, e.g. `ravelin.com`.
+	UserDomain string `yaml:"user_domain,omitempty"`
+	// GroupDomain is the email domain of groups, it defaults to the user domain.
+	GroupDomain string `yaml:"group_domain,omitempty"`
+	// GroupPrefix is prepended to the group file name to build the local part of
+	// the group email. This is a pointer as an empty prefix is a valid setting.
+	GroupPrefix *string `yaml:"group_prefix,omitempty"`
+	// GroupSuffix is appended to the group file name to build the local part of
+	// the group email.
+	GroupSuffix string `yaml:"group_suffix,omitempty"`
+	// UserSeparator replaces the underscores of user file names to build the
+	// local part of the user email, e.g. john_doe.yml becomes john.doe. This is
+	// a pointer as an empty separator is a valid setting.
+	UserSeparator *string `yaml:"user_separator,omitempty"`
+	// DomainOverrides maps subdirectories of the IAM directory, e.g.
+	// `users/contractors`, to the email domain of the files they contain. The
+	// override of the closest parent directory applies to nested directories.
+	DomainOverrides map[string]string `yaml:"domain_overrides,omitempty"`
+	// MaxGroupDepth is the maximum nesting depth of groups, direct groups being at
+	// depth 1. Defaults to DefaultMaxGroupDepth.
+	MaxGroupDepth int `yaml:"max_group_depth,omitempty"`
+	// ExpiryWarningDays is the number of days before their expiry entries are
+	// warned about. Defaults to DefaultExpiryWarningDays.
+	ExpiryWarningDays int `yaml:"expiry_warning_days,omitempty"`
+	// TwingateMerge are the strategies merging the Twingate settings inherited
+	// from groups, one of MergeStrategies. Defaults to MergePrimary.
+	TwingateMerge TwingateMerge `yaml:"twingate_merge,omitempty"`
+}
+
+// TwingateMerge are the merge strategies of each Twingate setting.
+type TwingateMerge struct {
+	Enabled string `yaml:"enabled,omitempty"`
+	Admin   string `yaml:"admin,omitempty"`
+}
+
+// LoadSettings reads the settings file from the root of the IAM directory. The
+// default settings are returned if the IAM directory has no settings file.
+// Unknown fields, values of the wrong type and unknown merge strategies are
+// reported with their position in the file, see Errors.
+func LoadSettings(iamDirectory string) (Settings, error) {
+	for _, name := range settingsFiles {
+		path := filepath.Join(iamDirectory, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return Settings{}, fmt.Errorf("error reading settings file %s: %w", path, err)
+		}
+
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return Settings{}, &ValidationError{File: path, Msg: err.Error()}
+		}
+		var s Settings
+		if len(doc.Content) == 0 {
+			return s, nil
+		}
+
+		v := &validator{file: path}
+		root := doc.Content[0]
+		v.checkNode(root, reflect.TypeOf(s), "")
+		for _, setting := range []string{"enabled", "admin"} {
+			if n := lookup(root, "twingate_merge", setting); n != nil && n.ShortTag() != "!!null" && !slices.Contains(MergeStrategies, n.Value) {
+				v.errorf(n, "unknown twingate merge strategy %q, expected one of %s", n.Value, strings.Join(MergeStrategies, ", "))
+			}
+		}
+		if err := v.err(); err != nil {
+			return Settings{}, err
+		}
+
+		if err := doc.Decode(&s); err != nil {
+			return Settings{}, &ValidationError{File: path, Msg: err.Error()}
+		}
+		return s, nil
+	}
+	return Settings{}, nil
+}
+
+// Merge returns the settings with the fields set in override replacing the
+// ones of s. Domain overrides are merged per directory.
+func (s Settings) Merge(override Settings) Settings {
+	if override.UserDomain != "" {
+		s.UserDomain = override.UserDomain
+	}
+	if override.GroupDomain != "" {
+		s.GroupDomain = override.GroupDomain
+	}
+	if override.GroupPrefix != nil {
+		s.GroupPrefix = override.GroupPrefix
+	}
+	if override.GroupSuffix != "" {
+		s.GroupSuffix = override.GroupSuffix
+	}
+	if override.UserSeparator != nil {
+		s.UserSeparator = override.UserSeparator
+	}
+	if override.MaxGroupDepth != 0 {
+		s.MaxGroupDepth = override.MaxGroupDepth
+	}
+	if override.ExpiryWarningDays != 0 {
+		s.ExpiryWarningDays = override.ExpiryWarningDays
+	}
+	if override.TwingateMerge.Enabled != "" {
+		s.TwingateMerge.Enabled = override.TwingateMerge.Enabled
+	}
+	if override.TwingateMerge.Admin != "" {
+		s.TwingateMerge.Admin = override.TwingateMerge.Admin
+	}
+
+	if len(override.DomainOverrides) > 0 {
+		overrides := make(map[string]string, len(s.DomainOverrides)+len(override.DomainOverrides))
+		for dir, domain := range s.DomainOverrides {
+			overrides[dir] = domain
+		}
+		for dir, domain := range override.DomainOverrides {
+			overrides[dir] = domain
+		}
+		s.DomainOverrides = overrides
+	}
+	return s
+}
+
+// userEmail returns the email of the user defined in the given file.
+func (s Settings) userEmail(file string) (string, error) {
+	parts := strings.Split(filepath.Base(file), ".")
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid user file: %s, expected format: <name>_<surname>.yml", file)
+	}
+
+	separator := DefaultUserSeparator
+	if s.UserSeparator != nil {
+		separator = *s.UserSeparator
+	}
+
+	domain := s.UserDomain
+	if domain == "" {
+		domain = DefaultUserDomain
+	}
+	return strings.ReplaceAll(parts[0], "_", separator) + "@" + s.domainOverride(file, domain), nil
+}
+
+// groupEmail returns the email of the group defined in the given file.
+func (s Settings) groupEmail(file string) (string, error) {
+	parts := strings.Split(filepath.Base(file), ".")
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid group file: %s, expected format: <group-name>.yml", file)
+	}
+	return s.GroupEmail(parts[0], file), nil
+}
+
+// GroupEmail returns the email of the group with the given name. The file is
+// used to look up domain overrides, it can be empty for groups without a file.
+func (s Settings) GroupEmail(name, file string) string {
+	prefix := DefaultGroupPrefix
+	if s.GroupPrefix != nil {
+		prefix = *s.GroupPrefix
+	}
+
+	domain := s.GroupDomain
+	if domain == "" {
+		domain = s.UserDomain
+	}
+	if domain == "" {
+		domain = DefaultUserDomain
+	}
+	if file != "" {
+		domain = s.domainOverride(file, domain)
+	}
+	return prefix + name + s.GroupSuffix + "@" + domain
+}
+
+// domainOverride returns the domain of the closest parent directory of the file
+// with an override, or the given domain if there are none. Override keys are
+// relative to the IAM directory, e.g. `users/contractors`.
+func (s Settings) domainOverride(file, domain string) string {
+	if len(s.DomainOverrides) == 0 {
+		return domain
+	}
+
+	rel, ok := entityRelativeDir(file)
+	if !ok {
+		return domain
+	}
+	for dir := rel; dir != "." && dir != "/"; dir = filepath.Dir(dir) {
+		if override, ok := s.DomainOverrides[filepath.ToSlash(dir)]; ok {
+			return override
+		}
+	}
+	return domain
+}
+
+// entityRelativeDir returns the directory of the file relative to the parent of
+// its entity directory, e.g. `users/contractors` for
+// `/iam/users/contractors/jane_doe.yml`.
+func entityRelativeDir(file string) (string, bool) {
+	dir := filepath.Dir(file)
+	for d := dir; d != "." && d != "/" && d != filepath.Dir(d); d = filepath.Dir(d) {
+		if _, ok := entityDirs[filepath.Base(d)]; ok {
+			rel, err := filepath.Rel(filepath.Dir(d), dir)
+			if err != nil {
+				return "", false
+			}
+			return rel, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,158 @@
+package ravelinaccess
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileToEmailSettings(t *testing.T) {
+	strPtr := func(v string) *string { return &v }
+
+	settings := Settings{
+		UserDomain:    "example.com",
+		GroupDomain:   "groups.example.com",
+		GroupPrefix:   strPtr(""),
+		GroupSuffix:   "-team",
+		UserSeparator: strPtr("_"),
+		DomainOverrides: map[string]string{
+			"users/contractors":  "contractors.example.com",
+			"groups/contractors": "contractors.example.com",
+		},
+	}
+
+	tests := []struct {
+		name      string
+		input     string
+		typ       EntityType
+		settings  Settings
+		expOutput string
+	}{
+		{
+			name:      "user_domain",
+			input:     "/iam/users/john_doe.yml",
+			typ:       USER,
+			settings:  settings,
+			expOutput: "john_doe@example.com",
+		},
+		{
+			name:      "user_domain_override",
+			input:     "/iam/users/contractors/jane_doe.yml",
+			typ:       USER,
+			settings:  settings,
+			expOutput: "jane_doe@contractors.example.com",
+		},
+		{
+			name:      "nested_user_domain_override",
+			input:     "/iam/users/contractors/acme/jane_doe.yml",
+			typ:       USER,
+			settings:  settings,
+			expOutput: "jane_doe@contractors.example.com",
+		},
+		{
+			name:      "group_naming",
+			input:     "/iam/groups/platform.yml",
+			typ:       GROUP,
+			settings:  settings,
+			expOutput: "platform-team@groups.example.com",
+		},
+		{
+			name:      "group_domain_override",
+			input:     "/iam/groups/contractors/acme.yml",
+			typ:       GROUP,
+			settings:  settings,
+			expOutput: "acme-team@contractors.example.com",
+		},
+		{
+			name:      "group_domain_defaults_to_user_domain",
+			input:     "/iam/groups/platform.yml",
+			typ:       GROUP,
+			settings:  Settings{UserDomain: "example.com"},
+			expOutput: "gcp-platform@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := fileToEmail(tt.input, tt.typ, tt.settings, "")
+			require.NoError(t, err)
+			require.Equal(t, tt.expOutput, out)
+		})
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	strPtr := func(v string) *string { return &v }
+
+	dir := createTempFiles(t, map[string][]byte{
+		"config.yml": []byte(`
+user_domain: example.com
+group_prefix: ""
+domain_overrides:
+  users/contractors: contractors.example.com
+`),
+	})
+
+	settings, err := LoadSettings(dir)
+	require.NoError(t, err)
+
+	expected := Settings{
+		UserDomain:      "example.com",
+		GroupPrefix:     strPtr(""),
+		DomainOverrides: map[string]string{"users/contractors": "contractors.example.com"},
+	}
+	if diff := cmp.Diff(expected, settings); diff != "" {
+		t.Errorf("expected settings (-) but got (+), %s", diff)
+	}
+
+	// provider settings take precedence over the settings file
+	merged := settings.Merge(Settings{
+		UserDomain:      "override.com",
+		DomainOverrides: map[string]string{"users/interns": "interns.example.com"},
+	})
+	expected = Settings{
+		UserDomain:  "override.com",
+		GroupPrefix: strPtr(""),
+		DomainOverrides: map[string]string{
+			"users/contractors": "contractors.example.com",
+			"users/interns":     "interns.example.com",
+		},
+	}
+	if diff := cmp.Diff(expected, merged); diff != "" {
+		t.Errorf("expected merged settings (-) but got (+), %s", diff)
+	}
+
+	// no settings file
+	settings, err = LoadSettings(t.TempDir())
+	require.NoError(t, err)
+	require.Equal(t, Settings{}, settings)
+}
+
+func TestLoadSettings_Invalid(t *testing.T) {
+	dir := createTempFiles(t, map[string][]byte{
+		"config.yml": []byte(`user_domain: example.com
+group_prefx: ""
+max_group_depth: deep
+domain_overrides:
+  - users/contractors
+twingate_merge:
+  enabled: most
+  admins: any
+`),
+	})
+
+	_, err := LoadSettings(dir)
+	expErrors := []string{
+		`config.yml:2:1: unknown field "group_prefx" in the file`,
+		"config.yml:3:18: `max_group_depth` must be an integer, got \"deep\"",
+		"config.yml:5:3: `domain_overrides` must be a mapping",
+		`config.yml:8:3: unknown field "admins" in ` + "`twingate_merge`",
+		`config.yml:7:12: unknown twingate merge strategy "most", expected one of primary, any, all`,
+	}
+	errs := Errors(err)
+	require.Len(t, errs, len(expErrors))
+	for i, expErr := range expErrors {
+		require.ErrorContains(t, errs[i], expErr)
+	}
+}
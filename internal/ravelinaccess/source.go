@@ -0,0 +1,40 @@
+package ravelinaccess
+
+// Source is where an effective grant or setting comes from.
+type Source struct {
+	// Group is the name of the group the grant is inherited from, empty for
+	// the user file itself.
+	Group string
+	// File is the path of the IAM file setting it.
+	File string
+}
+
+// String returns `user` for grants of the user file, `group:<name>` for grants
+// inherited from a group.
+func (s Source) String() string {
+	if s.Group == "" {
+		return "user"
+	}
+	return "group:" + s.Group
+}
+
+// userSource returns the source of the settings of the file itself.
+func (a *RavelinAccess) userSource() *Source {
+	return &Source{File: a.filePath}
+}
+
+// groupSource returns the source of the settings inherited from the group.
+func groupSource(group *RavelinAccess) *Source {
+	return &Source{Group: groupName(group.filePath), File: group.filePath}
+}
+
+// addSource records the source of the role of the project.
+func (g *GsudoAccess) addSource(project, role string, source *Source) {
+	if g.Sources == nil {
+		g.Sources = make(map[string]map[string][]Source)
+	}
+	if g.Sources[project] == nil {
+		g.Sources[project] = make(map[string][]Source)
+	}
+	g.Sources[project][role] = append(g.Sources[project][role], *source)
+}
@@ -3,8 +3,9 @@ package ravelinaccess
 import (
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 )
 
 // TwingateAccess represents the Twingate access configuration for a user or a group.
@@ -17,47 +18,171 @@ type TwingateAccess struct {
 	// a pointer as we might want to have "false" over a user override a group
 	// level access.
 	Admin *bool `yaml:"admin,omitempty"`
+	// Groups are the Twingate groups the user or the members of the group
+	// belong to. Users inherit the groups of every group they belong to.
+	Groups []string `yaml:"groups,omitempty"`
+	// Resources are the Twingate Resources the Twingate groups of the file are
+	// allowed to access. Users inherit the resources of every group they belong
+	// to.
+	Resources []string `yaml:"resources,omitempty"`
+	// Expires is the expiry of the Twingate access, a date or an RFC 3339
+	// timestamp. The block is dropped once expired.
+	Expires string `yaml:"expires,omitempty"`
+	// ExpiresAt is the effective expiry of the Twingate access, the zero time if
+	// it doesn't expire. Access inherited from a group expires with the
+	// membership to the group.
+	ExpiresAt time.Time `yaml:"-"`
+
+	// EnabledSource and AdminSource are the files deciding the enabled and
+	// admin settings, nil if no file sets them. They are set by
+	// InheritTwingateAccess.
+	EnabledSource *Source `yaml:"-"`
+	AdminSource   *Source `yaml:"-"`
 }
 
-// InheritTwingateAccess inherits the Twingate access from the primary group.
-// Any setting at the user level will override the group level setting.
+// Merge strategies of the Twingate settings inherited from groups.
+const (
+	// MergePrimary takes the setting of the primary group, the first group of
+	// the user, or of the nearest group it belongs to setting it.
+	MergePrimary = "primary"
+	// MergeAny grants the setting if any group the user belongs to, directly or
+	// not, grants it.
+	MergeAny = "any"
+	// MergeAll grants the setting if every group the user belongs to setting
+	// it, directly or not, grants it.
+	MergeAll = "all"
+)
+
+// MergeStrategies lists the valid merge strategies.
+var MergeStrategies = []string{MergePrimary, MergeAny, MergeAll}
+
+// InheritTwingateAccess inherits the Twingate access from the groups of the
+// user, each setting being merged with the strategy of the settings, the
+// primary group by default. Any setting at the user level will override the
+// group level setting. Twingate groups and resources are the union of those of
+// the user and of every group it belongs to.
 func (a *RavelinAccess) InheritTwingateAccess() error {
-	if a.Type != USER {
-		return errors.New("inheritance is only available for users")
+	if a.Type == GROUP {
+		return errors.New("inheritance is only available for users and service accounts")
 	}
 
+	if a.Twingate.Enabled != nil {
+		a.Twingate.EnabledSource = a.userSource()
+	}
+	if a.Twingate.Admin != nil {
+		a.Twingate.AdminSource = a.userSource()
+	}
+
+	groups, err := a.groupClosure(a.GCP.Groups)
+	if err != nil {
+		return fmt.Errorf("error resolving groups of %s: %w", a.Email, err)
+	}
+	for _, group := range groups {
+		a.Twingate.Groups = append(a.Twingate.Groups, group.Twingate.Groups...)
+		a.Twingate.Resources = append(a.Twingate.Resources, group.Twingate.Resources...)
+	}
+	slices.Sort(a.Twingate.Groups)
+	a.Twingate.Groups = slices.Compact(a.Twingate.Groups)
+	slices.Sort(a.Twingate.Resources)
+	a.Twingate.Resources = slices.Compact(a.Twingate.Resources)
+
 	// if we have no groups, we have nothing to do
 	if len(a.GCP.Groups) == 0 {
 		return nil
 	}
 
-	// we only inherit twingate access through the primary group
-	primaryGroupFile := a.GCP.Groups[0] + ".yml"
-
-	groupFile := filepath.Join(filepath.Dir(a.filePath), "..", "groups", primaryGroupFile)
+	if a.Twingate.Enabled == nil {
+		enabled, err := a.mergeTwingate(a.settings.TwingateMerge.Enabled, func(t TwingateAccess) *bool { return t.Enabled })
+		if err != nil {
+			return err
+		}
+		a.Twingate.Enabled, a.Twingate.EnabledSource = enabled.value, enabled.source
+		a.Twingate.ExpiresAt = earliest(a.Twingate.ExpiresAt, enabled.expiresAt)
+	}
 
-	data, err := readFile(groupFile)
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil
+	// admin access is only inherited by users with Twingate access
+	if a.Twingate.Admin == nil && a.Twingate.Enabled != nil && *a.Twingate.Enabled {
+		admin, err := a.mergeTwingate(a.settings.TwingateMerge.Admin, func(t TwingateAccess) *bool { return t.Admin })
+		if err != nil {
+			return err
+		}
+		if admin.value != nil {
+			a.Twingate.Admin, a.Twingate.AdminSource = admin.value, admin.source
+			a.Twingate.ExpiresAt = earliest(a.Twingate.ExpiresAt, admin.expiresAt)
 		}
-		return fmt.Errorf("error reading group file %s: %w", groupFile, err)
 	}
 
-	var group RavelinAccess
-	if err := group.extractAccess(data); err != nil {
-		return fmt.Errorf("error extracting group access: %w", err)
+	return nil
+}
+
+// mergedSetting is a Twingate setting merged from the groups of the user.
+type mergedSetting struct {
+	value *bool
+	// source is the group supplying the value.
+	source *Source
+	// expiresAt is the expiry of the value, the earliest expiry of the groups
+	// deciding it and of the memberships to these groups.
+	expiresAt time.Time
+}
+
+// mergeTwingate merges the setting of the groups of the user with the
+// strategy, nil if no group sets it.
+func (a *RavelinAccess) mergeTwingate(strategy string, setting func(TwingateAccess) *bool) (mergedSetting, error) {
+	if strategy == "" {
+		strategy = MergePrimary
 	}
 
-	// inherit twingate access from the group only if the user level settings are
-	// not set
-	if group.Twingate.Enabled != nil && a.Twingate.Enabled == nil {
-		a.Twingate.Enabled = group.Twingate.Enabled
+	// the primary strategy only looks at the primary group and the groups it
+	// belongs to, the others at every group
+	groups := a.GCP.Groups
+	if strategy == MergePrimary {
+		groups = groups[:1]
+	}
+	closure, err := a.groupClosure(groups)
+	if err != nil {
+		return mergedSetting{}, fmt.Errorf("error resolving groups of %s: %w", a.Email, err)
 	}
 
-	if a.Twingate.Enabled != nil && *a.Twingate.Enabled && group.Twingate.Admin != nil && a.Twingate.Admin == nil {
-		a.Twingate.Admin = group.Twingate.Admin
+	// groups are ordered from the nearest to the farthest
+	var deciding []*RavelinAccess
+	switch strategy {
+	case MergePrimary:
+		if i := slices.IndexFunc(closure, func(g *RavelinAccess) bool { return setting(g.Twingate) != nil }); i != -1 {
+			deciding = closure[i : i+1]
+		}
+	case MergeAny, MergeAll:
+		var granting, denying []*RavelinAccess
+		for _, group := range closure {
+			switch value := setting(group.Twingate); {
+			case value == nil:
+			case *value:
+				granting = append(granting, group)
+			default:
+				denying = append(denying, group)
+			}
+		}
+		switch {
+		case strategy == MergeAny && len(granting) > 0:
+			deciding = granting[:1]
+		case strategy == MergeAny && len(denying) > 0:
+			deciding = denying[:1]
+		case strategy == MergeAll && len(denying) > 0:
+			deciding = denying[:1]
+		case strategy == MergeAll && len(granting) > 0:
+			deciding = granting
+		}
+	default:
+		return mergedSetting{}, fmt.Errorf("unknown twingate merge strategy %q, expected one of %s", strategy, strings.Join(MergeStrategies, ", "))
 	}
 
-	return nil
+	if len(deciding) == 0 {
+		return mergedSetting{}, nil
+	}
+
+	memberships := a.membershipExpiries(closure)
+	merged := mergedSetting{value: setting(deciding[0].Twingate), source: groupSource(deciding[0])}
+	for _, group := range deciding {
+		merged.expiresAt = earliest(merged.expiresAt, earliest(memberships[group], group.Twingate.ExpiresAt))
+	}
+	return merged, nil
 }
@@ -21,6 +21,9 @@ func createTempFiles(t *testing.T, files ...map[string][]byte) string {
 
 	for _, typ := range files {
 		for path, data := range typ {
+			if err := os.MkdirAll(filepath.Dir(filepath.Join(tempDir, path)), 0755); err != nil {
+				t.Fatalf("error creating directory: %v", err)
+			}
 			err := os.WriteFile(filepath.Join(tempDir, path), data, 0644)
 			if err != nil {
 				t.Fatalf("error writing file: %v", err)
@@ -126,7 +129,7 @@ twingate:
 		t.Run(tt.name, func(t *testing.T) {
 			tempDir := createTempFiles(t, tt.userFile, tt.groupFiles)
 
-			access, err := ExtractRavelinAccess(filepath.Join(tempDir, "users", "john_doe.yml"))
+			access, err := ExtractRavelinAccess(filepath.Join(tempDir, "users", "john_doe.yml"), Settings{})
 			if err != nil {
 				t.Fatalf("error extracting user access: %v", err)
 			}
@@ -146,3 +149,221 @@ twingate:
 		})
 	}
 }
+
+func TestInheritTwingateAccess_MergeStrategies(t *testing.T) {
+	boolPtr := func(v bool) *bool { return &v }
+
+	groupFiles := map[string][]byte{
+		"groups/engineering.yml": []byte(`
+twingate:
+  enabled: false
+`),
+		"groups/oncall.yaml": []byte(`
+twingate:
+  enabled: true
+  admin: true
+`),
+		"groups/platform.yml": []byte(`
+gcp:
+  groups:
+    - oncall
+twingate:
+  enabled: true
+`),
+	}
+
+	tests := []struct {
+		name       string
+		userFile   []byte
+		merge      TwingateMerge
+		expEnabled *bool
+		expAdmin   *bool
+		expSources [2]string
+		expErr     bool
+	}{
+		{
+			name: "primary_by_default",
+			userFile: []byte(`
+gcp:
+  groups:
+    - engineering
+    - platform
+`),
+			expEnabled: boolPtr(false),
+			expSources: [2]string{"group:engineering", ""},
+		},
+		{
+			name: "primary_by_default_depends_on_order",
+			userFile: []byte(`
+gcp:
+  groups:
+    - platform
+    - engineering
+`),
+			expEnabled: boolPtr(true),
+			expAdmin:   boolPtr(true),
+			expSources: [2]string{"group:platform", "group:oncall"},
+		},
+		{
+			name: "all_independent_of_order",
+			userFile: []byte(`
+gcp:
+  groups:
+    - platform
+    - engineering
+`),
+			merge:      TwingateMerge{Enabled: MergeAll, Admin: MergeAll},
+			expEnabled: boolPtr(false),
+			expSources: [2]string{"group:engineering", ""},
+		},
+		{
+			name: "primary_nearest_group",
+			userFile: []byte(`
+gcp:
+  groups:
+    - platform
+`),
+			merge:      TwingateMerge{Enabled: MergePrimary, Admin: MergePrimary},
+			expEnabled: boolPtr(true),
+			expAdmin:   boolPtr(true),
+			expSources: [2]string{"group:platform", "group:oncall"},
+		},
+		{
+			name: "any_group_grants",
+			userFile: []byte(`
+gcp:
+  groups:
+    - engineering
+    - platform
+`),
+			merge:      TwingateMerge{Enabled: MergeAny, Admin: MergeAny},
+			expEnabled: boolPtr(true),
+			expAdmin:   boolPtr(true),
+			expSources: [2]string{"group:platform", "group:oncall"},
+		},
+		{
+			name: "all_groups_must_grant",
+			userFile: []byte(`
+gcp:
+  groups:
+    - engineering
+    - platform
+`),
+			merge:      TwingateMerge{Enabled: MergeAll},
+			expEnabled: boolPtr(false),
+			expSources: [2]string{"group:engineering", ""},
+		},
+		{
+			name: "all_groups_grant",
+			userFile: []byte(`
+gcp:
+  groups:
+    - platform
+`),
+			merge:      TwingateMerge{Enabled: MergeAll, Admin: MergeAll},
+			expEnabled: boolPtr(true),
+			expAdmin:   boolPtr(true),
+			expSources: [2]string{"group:platform", "group:oncall"},
+		},
+		{
+			name: "user_setting_wins",
+			userFile: []byte(`
+gcp:
+  groups:
+    - engineering
+    - platform
+twingate:
+  enabled: true
+  admin: false
+`),
+			merge:      TwingateMerge{Enabled: MergeAll, Admin: MergeAny},
+			expEnabled: boolPtr(true),
+			expAdmin:   boolPtr(false),
+			expSources: [2]string{"user", "user"},
+		},
+		{
+			name: "unknown_strategy",
+			userFile: []byte(`
+gcp:
+  groups:
+    - platform
+`),
+			merge:  TwingateMerge{Enabled: "most"},
+			expErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := createTempFiles(t, map[string][]byte{"users/john_doe.yml": tt.userFile}, groupFiles)
+
+			access, err := ExtractRavelinAccess(filepath.Join(tempDir, "users", "john_doe.yml"), Settings{TwingateMerge: tt.merge})
+			require.NoError(t, err)
+
+			err = access.InheritTwingateAccess()
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, tt.expEnabled, access.Twingate.Enabled)
+			require.Equal(t, tt.expAdmin, access.Twingate.Admin)
+			require.Equal(t, tt.expSources[0], sourceString(access.Twingate.EnabledSource))
+			require.Equal(t, tt.expSources[1], sourceString(access.Twingate.AdminSource))
+		})
+	}
+}
+
+func sourceString(s *Source) string {
+	if s == nil {
+		return ""
+	}
+	return s.String()
+}
+
+func TestInheritTwingateAccess_ResourcesAndGroups(t *testing.T) {
+	tempDir := createTempFiles(t, map[string][]byte{
+		"users/john_doe.yml": []byte(`
+gcp:
+  groups:
+    - payments
+twingate:
+  enabled: true
+  groups:
+    - vpn-users
+`),
+		"groups/payments.yml": []byte(`
+gcp:
+  groups:
+    - engineering
+twingate:
+  groups:
+    - payments-prod
+  resources:
+    - prod-db
+`),
+		"groups/engineering.yml": []byte(`
+twingate:
+  groups:
+    - engineering
+  resources:
+    - staging-dashboards
+    - prod-db
+`),
+	})
+
+	dir, err := LoadDirectory(tempDir, Settings{})
+	require.NoError(t, err)
+
+	access, ok := dir.ByEmail("john.doe@ravelin.com")
+	require.True(t, ok)
+	require.NoError(t, access.InheritTwingateAccess())
+	require.Equal(t, []string{"engineering", "payments-prod", "vpn-users"}, access.Twingate.Groups)
+	require.Equal(t, []string{"prod-db", "staging-dashboards"}, access.Twingate.Resources)
+
+	require.Equal(t, map[string][]string{
+		"prod-db":            {"engineering", "payments-prod"},
+		"staging-dashboards": {"engineering"},
+	}, dir.TwingateResources())
+}
@@ -0,0 +1,330 @@
+package ravelinaccess
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// projectIDRegex matches GCP project IDs.
+	projectIDRegex = regexp.MustCompile(`^[a-z][-a-z0-9]{4,28}[a-z0-9]$`)
+	// roleRegexes match the role references accepted in escalations: predefined
+	// roles, project custom roles in their short form and project custom roles.
+	roleRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`^roles/[a-zA-Z0-9_.]+$`),
+		regexp.MustCompile(`^custom/[a-zA-Z0-9_.]{3,64}$`),
+		regexp.MustCompile(`^projects/[a-z][-a-z0-9]{4,28}[a-z0-9]/roles/[a-zA-Z0-9_.]{3,64}$`),
+	}
+)
+
+// ValidationError is a problem found in an IAM file, located by its line and
+// column.
+type ValidationError struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
+// Errors returns the errors joined in err, flattening nested joins, so that
+// each problem can be reported on its own.
+func Errors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, Errors(e)...)
+	}
+	return errs
+}
+
+// validator collects the problems of an IAM file.
+type validator struct {
+	file string
+	errs []error
+	// bundles are the role bundles the roles of the file can reference.
+	bundles roleBundles
+	// projects is the projects inventory the project keys of the file are
+	// checked against and selectors match, nil if there is none.
+	projects projectInventory
+}
+
+func (v *validator) errorf(n *yaml.Node, format string, args ...any) {
+	v.errs = append(v.errs, &ValidationError{File: v.file, Line: n.Line, Column: n.Column, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) err() error {
+	return errors.Join(v.errs...)
+}
+
+// decodeStrict decodes the IAM file into out. Unlike yaml.Unmarshal, unknown
+// fields, values of the wrong type and invalid roles or project IDs are
+// reported, all at once, with their position in the file. The expiries of the
+// entries of the file and the requirements of its escalations are returned.
+// Roles can reference the bundles and project keys select projects of the
+// inventory.
+func decodeStrict(file string, data []byte, out any, bundles roleBundles, projects projectInventory) ([]expiry, []requirement, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, &ValidationError{File: file, Msg: err.Error()}
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil, nil
+	}
+
+	v := &validator{file: file, bundles: bundles, projects: projects}
+	requirements := v.extractRequirements(doc.Content[0])
+	expiries := v.extractExpiries(doc.Content[0])
+	v.checkNode(doc.Content[0], reflect.TypeOf(out).Elem(), "")
+	v.checkValues(doc.Content[0])
+	if err := v.err(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := doc.Decode(out); err != nil {
+		return nil, nil, &ValidationError{File: file, Msg: err.Error()}
+	}
+	return expiries, requirements, nil
+}
+
+// checkNode checks the node can be decoded into a value of type t, path being
+// the dotted path of the node used in messages.
+func (v *validator) checkNode(n *yaml.Node, t reflect.Type, path string) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null" {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			v.errorf(n, "%s must be a mapping", describe(path))
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				v.errorf(key, "unknown field %q in %s", key.Value, describe(path))
+				continue
+			}
+			v.checkNode(value, field.Type, join(path, key.Value))
+		}
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			v.errorf(n, "%s must be a mapping", describe(path))
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			v.checkNode(n.Content[i+1], t.Elem(), join(path, n.Content[i].Value))
+		}
+	case reflect.Slice:
+		if n.Kind != yaml.SequenceNode {
+			v.errorf(n, "%s must be a list", describe(path))
+			return
+		}
+		for _, item := range n.Content {
+			v.checkNode(item, t.Elem(), path)
+		}
+	case reflect.Bool:
+		if n.Kind != yaml.ScalarNode || n.ShortTag() != "!!bool" {
+			v.errorf(n, "%s must be a boolean, got %q", describe(path), n.Value)
+		}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		if n.Kind != yaml.ScalarNode || n.ShortTag() != "!!int" {
+			v.errorf(n, "%s must be an integer, got %q", describe(path), n.Value)
+		}
+	case reflect.String:
+		if n.Kind != yaml.ScalarNode {
+			v.errorf(n, "%s must be a string", describe(path))
+		}
+	}
+}
+
+// yamlFields returns the fields of the struct by their YAML key.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func describe(path string) string {
+	if path == "" {
+		return "the file"
+	}
+	return "`" + path + "`"
+}
+
+// checkValues checks the format of the identity fields, of the status, of
+// project IDs and of escalation and project roles.
+func (v *validator) checkValues(root *yaml.Node) {
+	if n := lookup(root, "email"); n != nil && !strings.Contains(n.Value, "@") {
+		v.errorf(n, "invalid email %q", n.Value)
+	}
+	if n := lookup(root, "manager"); n != nil && !strings.Contains(n.Value, "@") {
+		v.errorf(n, "invalid manager email %q", n.Value)
+	}
+	if n := lookup(root, "start_date"); n != nil {
+		if _, err := time.Parse(time.DateOnly, n.Value); err != nil {
+			v.errorf(n, "invalid start_date %q, expected format: YYYY-MM-DD", n.Value)
+		}
+	}
+	if n := lookup(root, "status"); n != nil && !slices.Contains(Statuses, n.Value) {
+		v.errorf(n, "invalid status %q, expected one of %s", n.Value, strings.Join(Statuses, ", "))
+	}
+	if n := lookup(root, "offboarded_at"); n != nil {
+		if _, err := time.Parse(time.DateOnly, n.Value); err != nil {
+			v.errorf(n, "invalid offboarded_at %q, expected format: YYYY-MM-DD", n.Value)
+		}
+		if status := lookup(root, "status"); status == nil || status.Value != StatusOffboarded {
+			v.errorf(n, "offboarded_at requires status %s", StatusOffboarded)
+		}
+	}
+	if n := lookup(root, "project"); n != nil && !projectIDRegex.MatchString(n.Value) {
+		v.errorf(n, "invalid project ID %q", n.Value)
+	}
+
+	// resources are granted to the Twingate groups of the same file
+	if n := lookup(root, "twingate", "resources"); n != nil && n.Kind == yaml.SequenceNode && len(n.Content) > 0 && lookup(root, "twingate", "groups") == nil {
+		v.errorf(n, "`twingate.resources` requires `twingate.groups` to grant the resources to")
+	}
+
+	v.checkRoles(lookup(root, "gsudo", "escalations"), "gsudo escalations")
+	v.checkRoles(lookup(root, "gcp", "roles"), "gcp roles")
+}
+
+// checkRoles checks the project IDs and roles of a map of projects to roles,
+// name being the name of the map used in messages.
+func (v *validator) checkRoles(n *yaml.Node, name string) {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		project, roles := n.Content[i], n.Content[i+1]
+		v.checkProject(project, name)
+		for _, role := range roles.Content {
+			v.checkRole(role, " for project "+project.Value)
+		}
+	}
+}
+
+// checkProject checks the project key of a map of projects to roles is a valid
+// project ID, of the inventory if there is one, or a selector matching projects
+// of the inventory.
+func (v *validator) checkProject(project *yaml.Node, name string) {
+	key := project.Value
+	if !isProjectSelector(key) {
+		switch {
+		case !projectIDRegex.MatchString(key):
+			v.errorf(project, "invalid project ID %q in %s", key, name)
+		case v.projects != nil:
+			if _, ok := v.projects[key]; !ok {
+				v.errorf(project, "unknown project %q in %s, expected a project of projects.yml", key, name)
+			}
+		}
+		return
+	}
+
+	selector, err := parseProjectSelector(key)
+	switch {
+	case err != nil:
+		v.errorf(project, "invalid project selector %q in %s: %s, expected globs, env:<environment>, team:<team> or label:<key>=<value>", key, name, err)
+	case v.projects == nil:
+		v.errorf(project, "project selector %q in %s requires a projects.yml inventory", key, name)
+	case len(selector.match(v.projects)) == 0:
+		v.errorf(project, "project selector %q in %s matches no project of projects.yml", key, name)
+	}
+}
+
+// checkRole checks the role is valid or references a known bundle, where
+// locating the role in messages.
+func (v *validator) checkRole(role *yaml.Node, where string) {
+	if name, ok := strings.CutPrefix(role.Value, bundlePrefix); ok {
+		if _, known := v.bundles[name]; !known {
+			v.errorf(role, "unknown bundle %q%s, expected a bundles/%s.yml file", name, where, name)
+		}
+		return
+	}
+	// empty roles are missing roles of the object form, already reported
+	if role.Value != "" && !validRole(role.Value) {
+		v.errorf(role, "invalid role %q%s, expected roles/<role>, custom/<role>, projects/<project>/roles/<role> or bundle:<name>", role.Value, where)
+	}
+}
+
+// lookup returns the node at the path of mapping keys, nil if it is missing.
+func lookup(n *yaml.Node, path ...string) *yaml.Node {
+	for _, key := range path {
+		if n.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == key {
+				next = n.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		n = next
+	}
+	if n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null" {
+		return nil
+	}
+	return n
+}
+
+func validRole(role string) bool {
+	for _, re := range roleRegexes {
+		if re.MatchString(role) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package sbom
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+)
+
+// maxFileSize is the maximum size of a package database or lockfile read from
+// the image, larger files are skipped.
+const maxFileSize = 64 << 20
+
+// Package types, they match the package URL (purl) types.
+const (
+	Deb    = "deb"
+	Apk    = "apk"
+	Rpm    = "rpm"
+	Npm    = "npm"
+	PyPI   = "pypi"
+	Cargo  = "cargo"
+	Gem    = "gem"
+	Golang = "golang"
+)
+
+// Package is a software package found in an image.
+type Package struct {
+	// Name of the package.
+	Name string
+	// Version of the package as recorded by the package manager.
+	Version string
+	// Type is the package manager type, e.g. deb or npm.
+	Type string
+	// Source is the name of the source package the OS package was built from,
+	// when it differs from the package name. Distribution advisories are
+	// published against source packages.
+	Source string
+	// Location is the path of the database or lockfile the package was found in.
+	Location string
+}
+
+// OSRelease identifies the distribution of an image from /etc/os-release.
+type OSRelease struct {
+	ID        string
+	VersionID string
+}
+
+// Catalog is the inventory of the packages installed in an image.
+type Catalog struct {
+	OS       OSRelease
+	Packages []Package
+	// Skipped are the package databases and lockfiles that could not be parsed,
+	// their packages are missing from the catalog.
+	Skipped []SkippedFile
+}
+
+// SkippedFile is a package database or lockfile that could not be parsed.
+type SkippedFile struct {
+	// Location is the path of the file in the image.
+	Location string
+	// Reason is the parsing error.
+	Reason string
+}
+
+func (f SkippedFile) String() string {
+	return f.Location + ": " + f.Reason
+}
+
+// rpmDatabases are the known locations of the rpm database, the format of the
+// database depends on the version of rpm (Berkeley DB, NDB or SQLite).
+var rpmDatabases = []string{
+	"var/lib/rpm/Packages",
+	"var/lib/rpm/Packages.db",
+	"var/lib/rpm/rpmdb.sqlite",
+	"usr/lib/sysimage/rpm/Packages.db",
+	"usr/lib/sysimage/rpm/rpmdb.sqlite",
+}
+
+// parser extracts packages from the content of a file found at the given path.
+type parser func(location string, data []byte) ([]Package, error)
+
+// matchParser returns the parser handling the file at the given path, or nil if
+// the file is neither a package database nor a supported lockfile.
+func matchParser(name string) parser {
+	switch {
+	case name == "var/lib/dpkg/status":
+		return parseDpkgStatus
+	case path.Dir(name) == "var/lib/dpkg/status.d" && !strings.HasSuffix(name, ".md5sums"):
+		// distroless images have one status file per package
+		return parseDpkgStatus
+	case name == "lib/apk/db/installed":
+		return parseApkInstalled
+	case slices.Contains(rpmDatabases, name):
+		return parseRpmDatabase
+	}
+
+	switch path.Base(name) {
+	case "package-lock.json":
+		return parseNpmLock
+	case "requirements.txt":
+		return parseRequirements
+	case "poetry.lock":
+		return parsePoetryLock
+	case "Cargo.lock":
+		return parseCargoLock
+	case "Gemfile.lock":
+		return parseGemfileLock
+	case "go.mod":
+		return parseGoMod
+	}
+	return nil
+}
+
+// CatalogImage walks the flattened filesystem of the image and catalogues the
+// OS packages from the dpkg, apk and rpm databases along with the language
+// packages pinned in lockfiles. Whiteouts are honoured so packages removed in
+// upper layers are not reported. Files that can't be parsed are skipped and
+// recorded in the Skipped field of the catalog, only failures to read the image
+// are errors.
+func CatalogImage(img v1.Image) (Catalog, error) {
+	rc := mutate.Extract(img)
+	defer rc.Close()
+	return catalogFilesystem(rc)
+}
+
+// catalogFilesystem catalogues the packages of a filesystem tarball.
+func catalogFilesystem(r io.Reader) (Catalog, error) {
+	var c Catalog
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return c, fmt.Errorf("error reading image filesystem: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || hdr.Size > maxFileSize {
+			continue
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+
+		if name == "etc/os-release" || (name == "usr/lib/os-release" && c.OS.ID == "") {
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return c, fmt.Errorf("error reading %s: %w", name, err)
+			}
+			c.OS = parseOSRelease(data)
+			continue
+		}
+
+		parse := matchParser(name)
+		if parse == nil {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return c, fmt.Errorf("error reading %s: %w", name, err)
+		}
+		pkgs, err := parse("/"+name, data)
+		if err != nil {
+			c.Skipped = append(c.Skipped, SkippedFile{Location: "/" + name, Reason: err.Error()})
+			continue
+		}
+		c.Packages = append(c.Packages, pkgs...)
+	}
+
+	c.Packages = dedupPackages(c.Packages)
+	return c, nil
+}
+
+// dedupPackages sorts the packages and removes the ones found more than once.
+func dedupPackages(pkgs []Package) []Package {
+	slices.SortFunc(pkgs, func(a, b Package) int {
+		return strings.Compare(a.Type+"/"+a.Name+"@"+a.Version+a.Location, b.Type+"/"+b.Name+"@"+b.Version+b.Location)
+	})
+	return slices.CompactFunc(pkgs, func(a, b Package) bool {
+		return a.Type == b.Type && a.Name == b.Name && a.Version == b.Version
+	})
+}
+
+// parseOSRelease extracts the distribution ID and version from os-release.
+func parseOSRelease(data []byte) OSRelease {
+	var rel OSRelease
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"'`)
+		switch key {
+		case "ID":
+			rel.ID = value
+		case "VERSION_ID":
+			rel.VersionID = value
+		}
+	}
+	return rel
+}
@@ -0,0 +1,95 @@
+package sbom
+
+import (
+	"archive/tar"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func filesystem(t *testing.T, files map[string]string) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return &buf
+}
+
+func TestCatalogFilesystem(t *testing.T) {
+	fs := filesystem(t, map[string]string{
+		"etc/os-release": "NAME=\"Debian GNU/Linux\"\nID=debian\nVERSION_ID=\"12\"\n",
+		"var/lib/dpkg/status": `Package: libc6
+Source: glibc (2.36-9)
+Status: install ok installed
+Version: 2.36-9+deb12u4
+Description: GNU C Library
+ continuation line
+
+Package: removed
+Status: deinstall ok config-files
+Version: 1.0
+`,
+		"var/lib/dpkg/status.d/tzdata": "Package: tzdata\nVersion: 2024a-0+deb12u1\n",
+		"lib/apk/db/installed":         "P:musl\nV:1.2.4-r2\nA:x86_64\no:musl\n\nP:ssl_client\nV:1.36.1-r15\no:busybox\n",
+		"app/package-lock.json": `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "app", "version": "1.0.0"},
+    "node_modules/@types/node": {"version": "20.11.0"},
+    "node_modules/express": {"version": "4.18.2"}
+  }
+}`,
+		"app/requirements.txt": "requests[socks]==2.31.0 ; python_version > '3.8'\nflask>=2.0\n# comment\n-r other.txt\n",
+		"app/poetry.lock":      "[[package]]\nname = \"urllib3\"\nversion = \"2.2.0\"\n\n[package.extras]\nsocks = [\"pysocks\"]\n",
+		"app/Cargo.lock":       "version = 3\n\n[[package]]\nname = \"serde\"\nversion = \"1.0.197\"\n",
+		"app/Gemfile.lock":     "GEM\n  remote: https://rubygems.org/\n  specs:\n    rack (3.0.9)\n      webrick (>= 1.0)\n\nPLATFORMS\n  ruby\n",
+		"app/go.mod":           "module example.com/app\n\ngo 1.22\n\nrequire github.com/google/uuid v1.6.0\n\nrequire (\n\tgolang.org/x/sys v0.18.0 // indirect\n)\n",
+		"app/README.md":        "not a lockfile",
+	})
+
+	c, err := catalogFilesystem(fs)
+	require.NoError(t, err)
+
+	require.Equal(t, OSRelease{ID: "debian", VersionID: "12"}, c.OS)
+	require.Equal(t, []Package{
+		{Name: "musl", Version: "1.2.4-r2", Type: Apk, Location: "/lib/apk/db/installed"},
+		{Name: "ssl_client", Version: "1.36.1-r15", Type: Apk, Source: "busybox", Location: "/lib/apk/db/installed"},
+		{Name: "serde", Version: "1.0.197", Type: Cargo, Location: "/app/Cargo.lock"},
+		{Name: "libc6", Version: "2.36-9+deb12u4", Type: Deb, Source: "glibc", Location: "/var/lib/dpkg/status"},
+		{Name: "tzdata", Version: "2024a-0+deb12u1", Type: Deb, Location: "/var/lib/dpkg/status.d/tzdata"},
+		{Name: "rack", Version: "3.0.9", Type: Gem, Location: "/app/Gemfile.lock"},
+		{Name: "github.com/google/uuid", Version: "v1.6.0", Type: Golang, Location: "/app/go.mod"},
+		{Name: "golang.org/x/sys", Version: "v0.18.0", Type: Golang, Location: "/app/go.mod"},
+		{Name: "@types/node", Version: "20.11.0", Type: Npm, Location: "/app/package-lock.json"},
+		{Name: "express", Version: "4.18.2", Type: Npm, Location: "/app/package-lock.json"},
+		{Name: "requests", Version: "2.31.0", Type: PyPI, Location: "/app/requirements.txt"},
+		{Name: "urllib3", Version: "2.2.0", Type: PyPI, Location: "/app/poetry.lock"},
+	}, c.Packages)
+}
+
+func TestCatalogFilesystem_Skipped(t *testing.T) {
+	fs := filesystem(t, map[string]string{
+		"app/package-lock.json": `{"lockfileVersion": 3, "packages": {`,
+		"app/Cargo.lock":        "version = 3\n\n[[package]]\nname = \"serde\"\nversion = \"1.0.197\"\n",
+	})
+
+	// a malformed lockfile is skipped, the rest of the image is still catalogued
+	c, err := catalogFilesystem(fs)
+	require.NoError(t, err)
+	require.Equal(t, []Package{{Name: "serde", Version: "1.0.197", Type: Cargo, Location: "/app/Cargo.lock"}}, c.Packages)
+	require.Len(t, c.Skipped, 1)
+	require.Equal(t, "/app/package-lock.json", c.Skipped[0].Location)
+}
+
+func TestRpmSourceName(t *testing.T) {
+	require.Equal(t, "glibc", rpmSourceName("glibc-2.34-60.el9.src.rpm", "glibc-common"))
+	require.Equal(t, "", rpmSourceName("bash-5.1.8-6.el9.src.rpm", "bash"))
+	require.Equal(t, "", rpmSourceName("", "gpg-pubkey"))
+}
@@ -0,0 +1,262 @@
+package sbom
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Format is the format of the rendered SBOM document.
+type Format string
+
+const (
+	SPDXJSON      Format = "spdx-json"
+	CycloneDXJSON Format = "cyclonedx-json"
+)
+
+// in-toto predicate types of the SBOM attestations, as used by cosign.
+const (
+	PredicateSPDX      = "https://spdx.dev/Document"
+	PredicateCycloneDX = "https://cyclonedx.org/bom"
+)
+
+// toolName is recorded as the creator of the SBOM documents.
+const toolName = "terraform-provider-ravelin"
+
+// PredicateType returns the in-toto predicate type of the attestation
+// carrying an SBOM document in this format.
+func (f Format) PredicateType() string {
+	if f == CycloneDXJSON {
+		return PredicateCycloneDX
+	}
+	return PredicateSPDX
+}
+
+// Subject describes the image the SBOM is generated for.
+type Subject struct {
+	// Name is the repository of the image, without tag or digest.
+	Name string
+	// Digest of the image manifest.
+	Digest string
+	// Created is the creation time of the image. It is used as the SBOM creation
+	// time so that documents, and their digests, are reproducible.
+	Created time.Time
+}
+
+// Render renders the catalog as an SBOM document in the given format.
+func Render(c Catalog, subject Subject, format Format) ([]byte, error) {
+	switch format {
+	case SPDXJSON:
+		return json.Marshal(spdxDocument(c, subject))
+	case CycloneDXJSON:
+		return json.Marshal(cycloneDXDocument(c, subject))
+	}
+	return nil, fmt.Errorf("unsupported SBOM format %q, expected %q or %q", format, SPDXJSON, CycloneDXJSON)
+}
+
+// PackageURL returns the package URL (purl) of the package, OS packages are
+// namespaced by the distribution of the image.
+func PackageURL(p Package, rel OSRelease) string {
+	var b strings.Builder
+	b.WriteString("pkg:" + p.Type + "/")
+
+	switch p.Type {
+	case Deb, Rpm, Apk:
+		namespace := rel.ID
+		if namespace == "" {
+			namespace = map[string]string{Deb: "debian", Rpm: "redhat", Apk: "alpine"}[p.Type]
+		}
+		b.WriteString(url.PathEscape(namespace) + "/")
+	}
+
+	// npm scopes and go module paths are namespaces, their segments are escaped
+	// individually so the separators are kept
+	segments := strings.Split(p.Name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	b.WriteString(strings.Join(segments, "/"))
+	b.WriteString("@" + url.PathEscape(p.Version))
+
+	if rel.ID != "" && rel.VersionID != "" && (p.Type == Deb || p.Type == Rpm || p.Type == Apk) {
+		b.WriteString("?distro=" + url.QueryEscape(rel.ID+"-"+rel.VersionID))
+	}
+	return b.String()
+}
+
+type spdxDoc struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+	Comment  string   `json:"comment,omitempty"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	SourceInfo       string            `json:"sourceInfo,omitempty"`
+	PrimaryPurpose   string            `json:"primaryPackagePurpose,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// spdxDocument builds an SPDX 2.3 document. The image is the described package
+// and contains every catalogued package.
+func spdxDocument(c Catalog, subject Subject) spdxDoc {
+	doc := spdxDoc{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              subject.Name + "@" + subject.Digest,
+		DocumentNamespace: "https://" + subject.Name + "/sbom/" + subject.Digest,
+		CreationInfo: spdxCreationInfo{
+			Created:  subject.Created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + toolName},
+		},
+		Packages: []spdxPackage{{
+			Name:             subject.Name,
+			SPDXID:           "SPDXRef-Image",
+			VersionInfo:      subject.Digest,
+			DownloadLocation: "NOASSERTION",
+			PrimaryPurpose:   "CONTAINER",
+		}},
+		Relationships: []spdxRelationship{{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: "SPDXRef-Image",
+		}},
+	}
+
+	// files that could not be parsed are recorded so that consumers know the
+	// document is incomplete
+	if len(c.Skipped) > 0 {
+		skipped := make([]string, len(c.Skipped))
+		for i, f := range c.Skipped {
+			skipped[i] = f.String()
+		}
+		doc.CreationInfo.Comment = "Files skipped as they could not be parsed:\n" + strings.Join(skipped, "\n")
+	}
+
+	for i, p := range c.Packages {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i+1)
+		doc.Packages = append(doc.Packages, spdxPackage{
+			Name:             p.Name,
+			SPDXID:           id,
+			VersionInfo:      p.Version,
+			DownloadLocation: "NOASSERTION",
+			SourceInfo:       "acquired package info from " + p.Location,
+			ExternalRefs: []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  PackageURL(p, c.OS),
+			}},
+		})
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID:      "SPDXRef-Image",
+			RelationshipType:   "CONTAINS",
+			RelatedSPDXElement: id,
+		})
+	}
+	return doc
+}
+
+type cycloneDXDoc struct {
+	BOMFormat    string               `json:"bomFormat"`
+	SpecVersion  string               `json:"specVersion"`
+	SerialNumber string               `json:"serialNumber"`
+	Version      int                  `json:"version"`
+	Metadata     cycloneDXMetadata    `json:"metadata"`
+	Components   []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp  string               `json:"timestamp"`
+	Tools      []cycloneDXComponent `json:"tools"`
+	Component  cycloneDXComponent   `json:"component"`
+	Properties []cycloneDXProperty  `json:"properties,omitempty"`
+}
+
+type cycloneDXComponent struct {
+	Type       string              `json:"type"`
+	BOMRef     string              `json:"bom-ref,omitempty"`
+	Name       string              `json:"name"`
+	Version    string              `json:"version,omitempty"`
+	PURL       string              `json:"purl,omitempty"`
+	Properties []cycloneDXProperty `json:"properties,omitempty"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// cycloneDXDocument builds a CycloneDX 1.5 document. The serial number is
+// derived from the image digest so the document is reproducible.
+func cycloneDXDocument(c Catalog, subject Subject) cycloneDXDoc {
+	sum := sha256.Sum256([]byte(subject.Name + "@" + subject.Digest))
+	sum[6] = (sum[6] & 0x0f) | 0x40 // version 4
+	sum[8] = (sum[8] & 0x3f) | 0x80 // variant 10
+	serial := fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+
+	doc := cycloneDXDoc{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: serial,
+		Version:      1,
+		Metadata: cycloneDXMetadata{
+			Timestamp: subject.Created.UTC().Format(time.RFC3339),
+			Tools:     []cycloneDXComponent{{Type: "application", Name: toolName}},
+			Component: cycloneDXComponent{
+				Type:    "container",
+				BOMRef:  subject.Name + "@" + subject.Digest,
+				Name:    subject.Name,
+				Version: subject.Digest,
+			},
+		},
+		Components: []cycloneDXComponent{},
+	}
+
+	for _, f := range c.Skipped {
+		doc.Metadata.Properties = append(doc.Metadata.Properties, cycloneDXProperty{Name: "skipped", Value: f.String()})
+	}
+
+	for _, p := range c.Packages {
+		purl := PackageURL(p, c.OS)
+		doc.Components = append(doc.Components, cycloneDXComponent{
+			Type:       "library",
+			BOMRef:     purl,
+			Name:       p.Name,
+			Version:    p.Version,
+			PURL:       purl,
+			Properties: []cycloneDXProperty{{Name: "location", Value: p.Location}},
+		})
+	}
+	return doc
+}
@@ -0,0 +1,94 @@
+package sbom
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageURL(t *testing.T) {
+	debian := OSRelease{ID: "debian", VersionID: "12"}
+
+	tests := []struct {
+		pkg      Package
+		rel      OSRelease
+		expected string
+	}{
+		{
+			pkg:      Package{Name: "libc6", Version: "2.36-9+deb12u4", Type: Deb},
+			rel:      debian,
+			expected: "pkg:deb/debian/libc6@2.36-9+deb12u4?distro=debian-12",
+		},
+		{
+			pkg:      Package{Name: "musl", Version: "1.2.4-r2", Type: Apk},
+			expected: "pkg:apk/alpine/musl@1.2.4-r2",
+		},
+		{
+			pkg:      Package{Name: "@types/node", Version: "20.11.0", Type: Npm},
+			rel:      debian,
+			expected: "pkg:npm/@types/node@20.11.0",
+		},
+		{
+			pkg:      Package{Name: "github.com/google/uuid", Version: "v1.6.0", Type: Golang},
+			expected: "pkg:golang/github.com/google/uuid@v1.6.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			require.Equal(t, tt.expected, PackageURL(tt.pkg, tt.rel))
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	c := Catalog{
+		OS:       OSRelease{ID: "alpine", VersionID: "3.19"},
+		Packages: []Package{{Name: "musl", Version: "1.2.4-r2", Type: Apk, Location: "/lib/apk/db/installed"}},
+		Skipped:  []SkippedFile{{Location: "/app/package-lock.json", Reason: "unexpected end of JSON input"}},
+	}
+	subject := Subject{
+		Name:    "europe-docker.pkg.dev/project/mirror/alpine",
+		Digest:  "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	t.Run("spdx", func(t *testing.T) {
+		out, err := Render(c, subject, SPDXJSON)
+		require.NoError(t, err)
+
+		var doc spdxDoc
+		require.NoError(t, json.Unmarshal(out, &doc))
+		require.Equal(t, "SPDX-2.3", doc.SPDXVersion)
+		require.Equal(t, "2024-01-02T03:04:05Z", doc.CreationInfo.Created)
+		require.Len(t, doc.Packages, 2)
+		require.Equal(t, "pkg:apk/alpine/musl@1.2.4-r2?distro=alpine-3.19", doc.Packages[1].ExternalRefs[0].ReferenceLocator)
+		require.Len(t, doc.Relationships, 2)
+		require.Contains(t, doc.CreationInfo.Comment, "/app/package-lock.json: unexpected end of JSON input")
+
+		// documents are reproducible
+		again, err := Render(c, subject, SPDXJSON)
+		require.NoError(t, err)
+		require.Equal(t, out, again)
+	})
+
+	t.Run("cyclonedx", func(t *testing.T) {
+		out, err := Render(c, subject, CycloneDXJSON)
+		require.NoError(t, err)
+
+		var doc cycloneDXDoc
+		require.NoError(t, json.Unmarshal(out, &doc))
+		require.Equal(t, "CycloneDX", doc.BOMFormat)
+		require.Regexp(t, `^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, doc.SerialNumber)
+		require.Equal(t, "container", doc.Metadata.Component.Type)
+		require.Len(t, doc.Components, 1)
+		require.Equal(t, []cycloneDXProperty{{Name: "skipped", Value: "/app/package-lock.json: unexpected end of JSON input"}}, doc.Metadata.Properties)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := Render(c, subject, Format("syft-json"))
+		require.Error(t, err)
+	})
+}
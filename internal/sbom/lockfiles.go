@@ -0,0 +1,210 @@
+package sbom
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// parseNpmLock parses a package-lock.json. Lockfile v2 and v3 list every
+// installed package under `packages` keyed by their node_modules path, v1 nests
+// them under `dependencies`.
+func parseNpmLock(location string, data []byte) ([]Package, error) {
+	type dependency struct {
+		Version      string                     `json:"version"`
+		Dependencies map[string]json.RawMessage `json:"dependencies"`
+	}
+	var lock struct {
+		Packages     map[string]dependency      `json:"packages"`
+		Dependencies map[string]json.RawMessage `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("invalid package-lock.json: %w", err)
+	}
+
+	var pkgs []Package
+	if len(lock.Packages) > 0 {
+		for key, dep := range lock.Packages {
+			idx := strings.LastIndex(key, "node_modules/")
+			if idx == -1 || dep.Version == "" {
+				// the root project has an empty key and is not a dependency
+				continue
+			}
+			name := key[idx+len("node_modules/"):]
+			pkgs = append(pkgs, Package{Name: name, Version: dep.Version, Type: Npm, Location: location})
+		}
+		return pkgs, nil
+	}
+
+	var walk func(deps map[string]json.RawMessage) error
+	walk = func(deps map[string]json.RawMessage) error {
+		for name, raw := range deps {
+			var dep dependency
+			if err := json.Unmarshal(raw, &dep); err != nil {
+				return fmt.Errorf("invalid dependency %s: %w", name, err)
+			}
+			if dep.Version != "" {
+				pkgs = append(pkgs, Package{Name: name, Version: dep.Version, Type: Npm, Location: location})
+			}
+			if err := walk(dep.Dependencies); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return pkgs, walk(lock.Dependencies)
+}
+
+// parseRequirements parses a pip requirements file. Only pinned requirements
+// (`name==version`) are reported as other specifiers don't identify a version.
+func parseRequirements(location string, data []byte) ([]Package, error) {
+	var pkgs []Package
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+		if i := strings.Index(line, ";"); i != -1 {
+			// drop environment markers
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+
+		name, version, ok := strings.Cut(line, "==")
+		if !ok {
+			continue
+		}
+		if i := strings.Index(name, "["); i != -1 {
+			// drop extras
+			name = name[:i]
+		}
+		version, _, _ = strings.Cut(version, " ")
+		pkgs = append(pkgs, Package{Name: strings.TrimSpace(name), Version: strings.TrimSpace(version), Type: PyPI, Location: location})
+	}
+	return pkgs, scanner.Err()
+}
+
+// parsePoetryLock parses a poetry.lock file.
+func parsePoetryLock(location string, data []byte) ([]Package, error) {
+	return parseTOMLPackageTables(location, data, PyPI)
+}
+
+// parseCargoLock parses a Cargo.lock file.
+func parseCargoLock(location string, data []byte) ([]Package, error) {
+	return parseTOMLPackageTables(location, data, Cargo)
+}
+
+// parseTOMLPackageTables parses the `[[package]]` tables shared by poetry and
+// cargo lockfiles. Only the top level name and version keys are read so a full
+// TOML parser is not needed.
+func parseTOMLPackageTables(location string, data []byte, typ string) ([]Package, error) {
+	var pkgs []Package
+	var current *Package
+
+	flush := func() {
+		if current != nil && current.Name != "" && current.Version != "" {
+			pkgs = append(pkgs, *current)
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "[[package]]":
+			flush()
+			current = &Package{Type: typ, Location: location}
+		case strings.HasPrefix(line, "["):
+			// any other table ends the package table
+			flush()
+		case current != nil:
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			value = strings.Trim(strings.TrimSpace(value), `"`)
+			switch strings.TrimSpace(key) {
+			case "name":
+				current.Name = value
+			case "version":
+				current.Version = value
+			}
+		}
+	}
+	flush()
+
+	return pkgs, scanner.Err()
+}
+
+// parseGemfileLock parses the gem specs of a Gemfile.lock. Specs are indented by
+// four spaces, their own dependencies by six.
+func parseGemfileLock(location string, data []byte) ([]Package, error) {
+	var pkgs []Package
+	inSpecs := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "  specs:":
+			inSpecs = true
+			continue
+		case line == "" || !strings.HasPrefix(line, "  "):
+			inSpecs = false
+			continue
+		}
+		if !inSpecs || !strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "     ") {
+			continue
+		}
+
+		name, version, ok := strings.Cut(strings.TrimSpace(line), " (")
+		if !ok {
+			continue
+		}
+		pkgs = append(pkgs, Package{Name: name, Version: strings.TrimSuffix(version, ")"), Type: Gem, Location: location})
+	}
+	return pkgs, scanner.Err()
+}
+
+// parseGoMod parses the requirements of a go.mod file, both in their single
+// line and block forms.
+func parseGoMod(location string, data []byte) ([]Package, error) {
+	var pkgs []Package
+	inBlock := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i != -1 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "require (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimPrefix(line, "require ")
+		case !inBlock:
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		pkgs = append(pkgs, Package{Name: fields[0], Version: fields[1], Type: Golang, Location: location})
+	}
+	return pkgs, scanner.Err()
+}
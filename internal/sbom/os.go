@@ -0,0 +1,147 @@
+package sbom
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	rpmdb "github.com/knqyf263/go-rpmdb/pkg"
+)
+
+// parseDpkgStatus parses a dpkg status file. Stanzas are separated by blank
+// lines and only packages in the installed state are reported. Distroless
+// status files don't have a Status field, their packages are always installed.
+func parseDpkgStatus(location string, data []byte) ([]Package, error) {
+	var pkgs []Package
+	for stanza := range strings.SplitSeq(string(data), "\n\n") {
+		fields := parseStanza(stanza)
+		name, version := fields["Package"], fields["Version"]
+		if name == "" || version == "" {
+			continue
+		}
+		if status, ok := fields["Status"]; ok && !strings.HasSuffix(status, " installed") {
+			continue
+		}
+		// the source field may carry the source version in parentheses
+		source, _, _ := strings.Cut(fields["Source"], " ")
+		if source == name {
+			source = ""
+		}
+		pkgs = append(pkgs, Package{Name: name, Version: version, Type: Deb, Source: source, Location: location})
+	}
+	return pkgs, nil
+}
+
+// parseStanza parses the `Key: value` fields of a debian control stanza,
+// continuation lines are ignored.
+func parseStanza(stanza string) map[string]string {
+	fields := make(map[string]string)
+	for line := range strings.SplitSeq(stanza, "\n") {
+		if line == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields[key] = strings.TrimSpace(value)
+	}
+	return fields
+}
+
+// parseApkInstalled parses the apk database. Each package is a block of
+// `<letter>:<value>` lines, P being the name, V the version and o the origin
+// (source) package.
+func parseApkInstalled(location string, data []byte) ([]Package, error) {
+	var pkgs []Package
+	var current Package
+
+	flush := func() {
+		if current.Name != "" && current.Version != "" {
+			if current.Source == current.Name {
+				current.Source = ""
+			}
+			current.Type = Apk
+			current.Location = location
+			pkgs = append(pkgs, current)
+		}
+		current = Package{}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "P:"):
+			current.Name = line[2:]
+		case strings.HasPrefix(line, "V:"):
+			current.Version = line[2:]
+		case strings.HasPrefix(line, "o:"):
+			current.Source = line[2:]
+		}
+	}
+	flush()
+
+	return pkgs, scanner.Err()
+}
+
+// parseRpmDatabase parses an rpm database. The database has to be written to
+// disk as the rpmdb library only reads from files.
+func parseRpmDatabase(location string, data []byte) ([]Package, error) {
+	dir, err := os.MkdirTemp("", "rpmdb")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	dbPath := filepath.Join(dir, filepath.Base(location))
+	if err := os.WriteFile(dbPath, data, 0600); err != nil {
+		return nil, err
+	}
+
+	db, err := rpmdb.Open(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening rpm database: %w", err)
+	}
+	defer db.Close()
+
+	infos, err := db.ListPackages()
+	if err != nil {
+		return nil, fmt.Errorf("error listing rpm packages: %w", err)
+	}
+
+	pkgs := make([]Package, 0, len(infos))
+	for _, info := range infos {
+		version := info.Version + "-" + info.Release
+		if info.Epoch != nil && *info.Epoch != 0 {
+			version = strconv.Itoa(*info.Epoch) + ":" + version
+		}
+		pkgs = append(pkgs, Package{Name: info.Name, Version: version, Type: Rpm, Source: rpmSourceName(info.SourceRpm, info.Name), Location: location})
+	}
+	return pkgs, nil
+}
+
+// rpmSourceName extracts the source package name from the source rpm file name,
+// e.g. glibc from glibc-2.34-60.el9.src.rpm. It returns an empty string when the
+// source package has the same name as the package.
+func rpmSourceName(sourceRpm, name string) string {
+	source := strings.TrimSuffix(sourceRpm, ".src.rpm")
+	// drop the release and version
+	for range 2 {
+		i := strings.LastIndex(source, "-")
+		if i == -1 {
+			return ""
+		}
+		source = source[:i]
+	}
+	if source == name {
+		return ""
+	}
+	return source
+}
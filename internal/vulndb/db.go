@@ -0,0 +1,282 @@
+package vulndb
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Vulnerability is an entry of the database in the OSV format, only the fields
+// used for matching are decoded.
+// See https://ossf.github.io/osv-schema/
+type Vulnerability struct {
+	ID               string           `json:"id"`
+	Aliases          []string         `json:"aliases"`
+	Withdrawn        string           `json:"withdrawn"`
+	Severity         []SeverityScore  `json:"severity"`
+	Affected         []Affected       `json:"affected"`
+	DatabaseSpecific databaseSpecific `json:"database_specific"`
+}
+
+// SeverityScore is a severity score of a vulnerability, e.g. a CVSS vector.
+type SeverityScore struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+// Affected describes the versions of a package affected by a vulnerability.
+type Affected struct {
+	Package           AffectedPackage  `json:"package"`
+	Ranges            []Range          `json:"ranges"`
+	Versions          []string         `json:"versions"`
+	DatabaseSpecific  databaseSpecific `json:"database_specific"`
+	EcosystemSpecific databaseSpecific `json:"ecosystem_specific"`
+}
+
+// AffectedPackage identifies a package within an ecosystem.
+type AffectedPackage struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+}
+
+// Range is a list of events delimiting the affected versions.
+type Range struct {
+	Type   string  `json:"type"`
+	Events []Event `json:"events"`
+}
+
+// Event is a version at which a package starts or stops being affected.
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+// databaseSpecific holds the severity some databases, e.g. GitHub advisories,
+// record outside of the severity scores.
+type databaseSpecific struct {
+	Severity string `json:"severity"`
+}
+
+// Database is an in-memory vulnerability database indexed by ecosystem and
+// package name.
+type Database struct {
+	vulns  map[string][]*Vulnerability
+	digest string
+}
+
+// cacheEntry is a database loaded from a path, along with the digest of the
+// content of the path when it was loaded.
+type cacheEntry struct {
+	digest string
+	db     *Database
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = map[string]cacheEntry{}
+)
+
+// Open loads the database at path. The path can be a JSON file holding a single
+// OSV entry or a list of them, a zip archive of OSV entries as exported by
+// osv.dev, or a directory of OSV JSON files. Databases are cached for as long as
+// their content doesn't change as they are loaded both at plan and apply time.
+func Open(path string) (*Database, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening vulnerability database: %w", err)
+	}
+
+	digest, err := Digest(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	if entry, ok := cache[path]; ok && entry.digest == digest {
+		return entry.db, nil
+	}
+
+	db := &Database{vulns: map[string][]*Vulnerability{}, digest: digest}
+	switch {
+	case info.IsDir():
+		err = db.loadDir(path)
+	case strings.HasSuffix(path, ".zip"):
+		err = db.loadZip(path)
+	default:
+		var data []byte
+		data, err = os.ReadFile(path)
+		if err == nil {
+			err = db.load(path, data)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cache[path] = cacheEntry{digest: digest, db: db}
+	return db, nil
+}
+
+// Digest returns the SHA-256 digest of the content of the database at path, so
+// that a scan can be repeated when the database is updated. The digest of a
+// directory covers the name and content of every JSON file under it.
+func Digest(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("error opening vulnerability database: %w", err)
+	}
+
+	h := sha256.New()
+	if info.IsDir() {
+		err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(file, ".json") {
+				return err
+			}
+			rel, err := filepath.Rel(path, file)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(rel), len(data))
+			h.Write(data)
+			return nil
+		})
+	} else {
+		var f *os.File
+		f, err = os.Open(path)
+		if err == nil {
+			_, err = io.Copy(h, f)
+			f.Close()
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading vulnerability database: %w", err)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// loadDir loads every JSON file found under dir.
+func (db *Database) loadDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return db.load(path, data)
+	})
+}
+
+// loadZip loads every JSON file of a zip archive.
+func (db *Database) loadZip(path string) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("error opening vulnerability database: %w", err)
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if !strings.HasSuffix(f.Name, ".json") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		if err := db.load(path+":"+f.Name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// load decodes a single OSV entry or a list of entries and indexes them.
+func (db *Database) load(location string, data []byte) error {
+	var vulns []*Vulnerability
+
+	data = bytes.TrimSpace(data)
+	switch {
+	case len(data) == 0:
+		return nil
+	case data[0] == '[':
+		if err := json.Unmarshal(data, &vulns); err != nil {
+			return fmt.Errorf("invalid vulnerability database %s: %w", location, err)
+		}
+	default:
+		var vuln Vulnerability
+		if err := json.Unmarshal(data, &vuln); err != nil {
+			return fmt.Errorf("invalid vulnerability database %s: %w", location, err)
+		}
+		vulns = append(vulns, &vuln)
+	}
+
+	for _, vuln := range vulns {
+		if vuln.ID == "" {
+			return errors.New("invalid vulnerability database " + location + ": entry without id")
+		}
+		if vuln.Withdrawn != "" {
+			continue
+		}
+		db.add(vuln)
+	}
+	return nil
+}
+
+// add indexes the vulnerability under every package it affects.
+func (db *Database) add(vuln *Vulnerability) {
+	seen := map[string]bool{}
+	for _, affected := range vuln.Affected {
+		base, _, _ := strings.Cut(affected.Package.Ecosystem, ":")
+		key := indexKey(base, affected.Package.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		db.vulns[key] = append(db.vulns[key], vuln)
+	}
+}
+
+// Len returns the number of indexed vulnerabilities.
+func (db *Database) Len() int {
+	ids := map[string]bool{}
+	for _, vulns := range db.vulns {
+		for _, vuln := range vulns {
+			ids[vuln.ID] = true
+		}
+	}
+	return len(ids)
+}
+
+// indexKey returns the key a package is indexed under. PyPI names are
+// normalised as per PEP 503.
+func indexKey(ecosystem, name string) string {
+	if ecosystem == "PyPI" {
+		name = strings.ToLower(name)
+		name = strings.NewReplacer("_", "-", ".", "-").Replace(name)
+	}
+	return ecosystem + "/" + name
+}
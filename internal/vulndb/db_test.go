@@ -0,0 +1,44 @@
+package vulndb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Debian", "CVE-2024-0001.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, os.WriteFile(path, []byte(`{"id": "CVE-2024-0001"}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not an entry"), 0600))
+
+	digest, err := Digest(dir)
+	require.NoError(t, err)
+	db, err := Open(dir)
+	require.NoError(t, err)
+	require.Equal(t, digest, db.digest)
+
+	// files other than the entries don't change the digest
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("still not an entry"), 0600))
+	unchanged, err := Digest(dir)
+	require.NoError(t, err)
+	require.Equal(t, digest, unchanged)
+
+	// updating an entry in place changes the digest, and the cached database is
+	// loaded again even though the directory itself wasn't modified
+	require.NoError(t, os.WriteFile(path, []byte(`{"id": "CVE-2024-0001", "aliases": ["GHSA-xxxx-yyyy-zzzz"]}`), 0600))
+	updated, err := Digest(dir)
+	require.NoError(t, err)
+	require.NotEqual(t, digest, updated)
+
+	reloaded, err := Open(dir)
+	require.NoError(t, err)
+	require.NotSame(t, db, reloaded)
+	require.Equal(t, updated, reloaded.digest)
+
+	_, err = Digest(filepath.Join(dir, "missing.json"))
+	require.Error(t, err)
+}
@@ -0,0 +1,309 @@
+package vulndb
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/ravelin-community/terraform-provider-ravelin/internal/sbom"
+)
+
+// Finding is a vulnerability affecting a package of the catalog.
+type Finding struct {
+	// ID of the vulnerability in the database, e.g. a CVE or GHSA ID.
+	ID string
+	// Aliases of the vulnerability, e.g. the CVE ID of a GHSA advisory.
+	Aliases []string
+	// Package affected by the vulnerability.
+	Package sbom.Package
+	// Severity of the vulnerability.
+	Severity Severity
+	// FixedVersion is the first version of the package fixing the
+	// vulnerability, empty if no fix is available.
+	FixedVersion string
+}
+
+// Match returns the vulnerabilities affecting the packages of the catalog,
+// sorted by decreasing severity.
+func (db *Database) Match(c sbom.Catalog) []Finding {
+	var findings []Finding
+	for _, pkg := range c.Packages {
+		ecosystem, release := ecosystemOf(pkg, c.OS)
+		if ecosystem == "" {
+			continue
+		}
+
+		names := []string{pkg.Name}
+		if pkg.Source != "" {
+			names = append(names, pkg.Source)
+		}
+
+		seen := map[string]bool{}
+		for _, name := range names {
+			for _, vuln := range db.vulns[indexKey(ecosystem, name)] {
+				if seen[vuln.ID] {
+					continue
+				}
+				for _, affected := range vuln.Affected {
+					if !affects(affected, ecosystem, release, name) {
+						continue
+					}
+					fixed, ok := affectedVersion(affected, ecosystem, pkg.Version)
+					if !ok {
+						continue
+					}
+					seen[vuln.ID] = true
+					findings = append(findings, Finding{
+						ID:           vuln.ID,
+						Aliases:      vuln.Aliases,
+						Package:      pkg,
+						Severity:     vuln.severity(affected),
+						FixedVersion: fixed,
+					})
+					break
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		if a.Severity != b.Severity {
+			return cmpInt(int(b.Severity), int(a.Severity))
+		}
+		return strings.Compare(a.ID+a.Package.Name, b.ID+b.Package.Name)
+	})
+	return findings
+}
+
+// ecosystemOf returns the OSV ecosystem of the package, along with the
+// distribution release OS advisories are scoped to. An empty ecosystem is
+// returned for packages that can't be matched.
+func ecosystemOf(pkg sbom.Package, rel sbom.OSRelease) (string, string) {
+	switch pkg.Type {
+	case sbom.Deb:
+		if rel.ID == "ubuntu" {
+			return "Ubuntu", rel.VersionID
+		}
+		major, _, _ := strings.Cut(rel.VersionID, ".")
+		return "Debian", major
+	case sbom.Apk:
+		parts := strings.Split(rel.VersionID, ".")
+		if len(parts) < 2 {
+			return "Alpine", ""
+		}
+		return "Alpine", "v" + parts[0] + "." + parts[1]
+	case sbom.Rpm:
+		ecosystem := map[string]string{
+			"rhel":          "Red Hat",
+			"rocky":         "Rocky Linux",
+			"almalinux":     "AlmaLinux",
+			"sles":          "SUSE",
+			"opensuse-leap": "openSUSE",
+		}[rel.ID]
+		return ecosystem, ""
+	case sbom.Npm:
+		return "npm", ""
+	case sbom.PyPI:
+		return "PyPI", ""
+	case sbom.Cargo:
+		return "crates.io", ""
+	case sbom.Gem:
+		return "RubyGems", ""
+	case sbom.Golang:
+		return "Go", ""
+	}
+	return "", ""
+}
+
+// affects returns true if the affected entry is about the package in the given
+// ecosystem release. Entries scoped to a release, e.g. `Debian:12`, only match
+// packages of that release, unscoped entries match all releases.
+func affects(affected Affected, ecosystem, release, name string) bool {
+	if indexKey(ecosystem, name) != indexKey(strings.SplitN(affected.Package.Ecosystem, ":", 2)[0], affected.Package.Name) {
+		return false
+	}
+
+	_, scope, scoped := strings.Cut(affected.Package.Ecosystem, ":")
+	if !scoped || release == "" {
+		return true
+	}
+	return scope == release || strings.HasPrefix(scope, release+":")
+}
+
+// affectedVersion returns true if the version is affected along with the first
+// version fixing it, if any.
+func affectedVersion(affected Affected, ecosystem, version string) (string, bool) {
+	if slices.Contains(affected.Versions, version) {
+		return firstFixed(affected, ecosystem, version), true
+	}
+
+	for _, r := range affected.Ranges {
+		if r.Type != "ECOSYSTEM" && r.Type != "SEMVER" {
+			continue
+		}
+		if inRange(r, ecosystem, version) {
+			return firstFixed(affected, ecosystem, version), true
+		}
+	}
+	return "", false
+}
+
+// inRange evaluates the events of the range in version order, each event
+// switching the version in or out of the affected state.
+func inRange(r Range, ecosystem, version string) bool {
+	events := slices.Clone(r.Events)
+	slices.SortStableFunc(events, func(a, b Event) int {
+		return compareEventVersions(ecosystem, eventVersion(a), eventVersion(b))
+	})
+
+	affected := false
+	for _, e := range events {
+		switch {
+		case e.Introduced != "":
+			if e.Introduced == "0" || compareVersions(ecosystem, version, e.Introduced) >= 0 {
+				affected = true
+			}
+		case e.Fixed != "":
+			if compareVersions(ecosystem, version, e.Fixed) >= 0 {
+				affected = false
+			}
+		case e.LastAffected != "":
+			if compareVersions(ecosystem, version, e.LastAffected) > 0 {
+				affected = false
+			}
+		case e.Limit != "":
+			if e.Limit != "*" && compareVersions(ecosystem, version, e.Limit) >= 0 {
+				affected = false
+			}
+		}
+	}
+	return affected
+}
+
+// firstFixed returns the lowest fixed version greater than version.
+func firstFixed(affected Affected, ecosystem, version string) string {
+	var fixed string
+	for _, r := range affected.Ranges {
+		for _, e := range r.Events {
+			if e.Fixed == "" || compareVersions(ecosystem, e.Fixed, version) <= 0 {
+				continue
+			}
+			if fixed == "" || compareVersions(ecosystem, e.Fixed, fixed) < 0 {
+				fixed = e.Fixed
+			}
+		}
+	}
+	return fixed
+}
+
+func eventVersion(e Event) string {
+	switch {
+	case e.Introduced != "":
+		return e.Introduced
+	case e.Fixed != "":
+		return e.Fixed
+	case e.LastAffected != "":
+		return e.LastAffected
+	}
+	return e.Limit
+}
+
+// compareEventVersions orders event versions, `0` being the lowest version.
+func compareEventVersions(ecosystem, a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "0":
+		return -1
+	case b == "0":
+		return 1
+	}
+	return compareVersions(ecosystem, a, b)
+}
+
+// Gate decides which findings block an image.
+type Gate struct {
+	// Threshold is the minimum severity of the blocking findings.
+	Threshold Severity
+	// Allow lists vulnerability IDs, or aliases, that never block.
+	Allow []string
+	// IgnoreUnfixed ignores findings without a fixed version.
+	IgnoreUnfixed bool
+}
+
+// Result is the outcome of the gate.
+type Result struct {
+	// Counts is the number of findings per severity, allow-listed findings are
+	// not counted.
+	Counts map[string]int64
+	// Blocking are the findings failing the gate.
+	Blocking []Finding
+	// Skipped are the files of the image that couldn't be parsed, so their
+	// packages weren't scanned.
+	Skipped []sbom.SkippedFile
+	// Database is the digest of the content of the database, see Digest.
+	Database string
+}
+
+// Evaluate applies the gate to the findings.
+func (g Gate) Evaluate(findings []Finding) Result {
+	res := Result{Counts: map[string]int64{}}
+	for _, s := range Severities {
+		res.Counts[s.String()] = 0
+	}
+
+	for _, f := range findings {
+		if g.allowed(f) {
+			continue
+		}
+		res.Counts[f.Severity.String()]++
+
+		if f.Severity >= g.Threshold && (!g.IgnoreUnfixed || f.FixedVersion != "") {
+			res.Blocking = append(res.Blocking, f)
+		}
+	}
+	return res
+}
+
+func (g Gate) allowed(f Finding) bool {
+	for _, id := range g.Allow {
+		if strings.EqualFold(id, f.ID) || slices.ContainsFunc(f.Aliases, func(alias string) bool { return strings.EqualFold(id, alias) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary describes the blocking findings, one per line.
+func (r Result) Summary() string {
+	var b strings.Builder
+	for _, f := range r.Blocking {
+		fix := "no fix available"
+		if f.FixedVersion != "" {
+			fix = "fixed in " + f.FixedVersion
+		}
+		fmt.Fprintf(&b, "%s (%s): %s %s in %s, %s\n", f.ID, f.Severity, f.Package.Name, f.Package.Version, f.Package.Location, fix)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// ScanImage catalogues the packages of the image, matches them against the
+// database at dbPath and applies the gate. Files that can't be parsed are
+// skipped and listed in the result rather than failing the scan.
+func ScanImage(img v1.Image, dbPath string, gate Gate) (Result, error) {
+	db, err := Open(dbPath)
+	if err != nil {
+		return Result{}, err
+	}
+
+	catalog, err := sbom.CatalogImage(img)
+	if err != nil {
+		return Result{}, fmt.Errorf("error cataloguing image packages: %w", err)
+	}
+
+	res := gate.Evaluate(db.Match(catalog))
+	res.Skipped = catalog.Skipped
+	res.Database = db.digest
+	return res, nil
+}
@@ -0,0 +1,179 @@
+package vulndb
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/ravelin-community/terraform-provider-ravelin/internal/sbom"
+	"github.com/stretchr/testify/require"
+)
+
+const testDB = `[
+  {
+    "id": "CVE-2024-0001",
+    "affected": [{
+      "package": {"ecosystem": "Debian:12", "name": "glibc"},
+      "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "2.36-9+deb12u5"}]}]
+    }],
+    "severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}]
+  },
+  {
+    "id": "CVE-2024-0002",
+    "affected": [{
+      "package": {"ecosystem": "Debian:11", "name": "glibc"},
+      "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "2.31-13+deb11u9"}]}]
+    }]
+  },
+  {
+    "id": "CVE-2024-0003",
+    "affected": [{
+      "package": {"ecosystem": "Debian:12", "name": "tzdata"},
+      "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}]}]
+    }],
+    "database_specific": {"severity": "high"}
+  },
+  {
+    "id": "GHSA-xxxx-yyyy-zzzz",
+    "aliases": ["CVE-2024-0004"],
+    "affected": [{
+      "package": {"ecosystem": "npm", "name": "express"},
+      "ranges": [{"type": "SEMVER", "events": [{"introduced": "4.0.0"}, {"fixed": "4.19.2"}]}]
+    }],
+    "database_specific": {"severity": "MODERATE"}
+  },
+  {
+    "id": "GHSA-aaaa-bbbb-cccc",
+    "affected": [{
+      "package": {"ecosystem": "PyPI", "name": "Requests"},
+      "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "2.0.0"}, {"last_affected": "2.30.0"}]}]
+    }],
+    "database_specific": {"severity": "CRITICAL"}
+  },
+  {
+    "id": "GHSA-withdrawn",
+    "withdrawn": "2024-01-01T00:00:00Z",
+    "affected": [{"package": {"ecosystem": "npm", "name": "express"}, "versions": ["4.18.2"]}]
+  }
+]`
+
+func testCatalog() sbom.Catalog {
+	return sbom.Catalog{
+		OS: sbom.OSRelease{ID: "debian", VersionID: "12"},
+		Packages: []sbom.Package{
+			{Name: "libc6", Version: "2.36-9+deb12u4", Type: sbom.Deb, Source: "glibc"},
+			{Name: "tzdata", Version: "2024a-0+deb12u1", Type: sbom.Deb},
+			{Name: "express", Version: "4.18.2", Type: sbom.Npm},
+			{Name: "requests", Version: "2.31.0", Type: sbom.PyPI},
+		},
+	}
+}
+
+func TestMatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "osv.json")
+	require.NoError(t, os.WriteFile(path, []byte(testDB), 0600))
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	require.Equal(t, 5, db.Len())
+
+	findings := db.Match(testCatalog())
+	require.Equal(t, []Finding{
+		{ID: "CVE-2024-0001", Package: testCatalog().Packages[0], Severity: Critical, FixedVersion: "2.36-9+deb12u5"},
+		{ID: "CVE-2024-0003", Package: testCatalog().Packages[1], Severity: High},
+		{ID: "GHSA-xxxx-yyyy-zzzz", Aliases: []string{"CVE-2024-0004"}, Package: testCatalog().Packages[2], Severity: Medium, FixedVersion: "4.19.2"},
+	}, findings)
+}
+
+func TestGate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "osv")
+	require.NoError(t, os.MkdirAll(path, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(path, "all.json"), []byte(testDB), 0600))
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	findings := db.Match(testCatalog())
+
+	tests := []struct {
+		name     string
+		gate     Gate
+		counts   map[string]int64
+		blocking []string
+	}{
+		{
+			name:     "critical",
+			gate:     Gate{Threshold: Critical},
+			counts:   map[string]int64{"critical": 1, "high": 1, "medium": 1, "low": 0, "unknown": 0},
+			blocking: []string{"CVE-2024-0001"},
+		},
+		{
+			name:     "high without fix",
+			gate:     Gate{Threshold: High, IgnoreUnfixed: true},
+			counts:   map[string]int64{"critical": 1, "high": 1, "medium": 1, "low": 0, "unknown": 0},
+			blocking: []string{"CVE-2024-0001"},
+		},
+		{
+			name:     "allow listed alias",
+			gate:     Gate{Threshold: Medium, Allow: []string{"CVE-2024-0001", "cve-2024-0004"}},
+			counts:   map[string]int64{"critical": 0, "high": 1, "medium": 0, "low": 0, "unknown": 0},
+			blocking: []string{"CVE-2024-0003"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tt.gate.Evaluate(findings)
+			require.Equal(t, tt.counts, res.Counts)
+
+			var blocking []string
+			for _, f := range res.Blocking {
+				blocking = append(blocking, f.ID)
+			}
+			require.Equal(t, tt.blocking, blocking)
+		})
+	}
+}
+
+func TestScanImage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "osv.json")
+	require.NoError(t, os.WriteFile(path, []byte(testDB), 0600))
+
+	files := map[string]string{
+		"app/package-lock.json":   `{"lockfileVersion": 3, "packages": {"node_modules/express": {"version": "4.18.2"}}}`,
+		"other/package-lock.json": `{"lockfileVersion": 3, "packages": {`,
+	}
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	})
+	require.NoError(t, err)
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	require.NoError(t, err)
+
+	// the malformed lockfile doesn't fail the scan, the other one is still
+	// matched against the database
+	res, err := ScanImage(img, path, Gate{Threshold: Medium})
+	require.NoError(t, err)
+	require.Len(t, res.Blocking, 1)
+	require.Equal(t, "GHSA-xxxx-yyyy-zzzz", res.Blocking[0].ID)
+	require.Len(t, res.Skipped, 1)
+	require.Equal(t, "/other/package-lock.json", res.Skipped[0].Location)
+
+	digest, err := Digest(path)
+	require.NoError(t, err)
+	require.Equal(t, digest, res.Database)
+}
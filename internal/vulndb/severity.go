@@ -0,0 +1,186 @@
+package vulndb
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Severity of a vulnerability.
+type Severity int
+
+const (
+	Unknown Severity = iota
+	Low
+	Medium
+	High
+	Critical
+)
+
+// Severities lists the severities from lowest to highest.
+var Severities = []Severity{Unknown, Low, Medium, High, Critical}
+
+func (s Severity) String() string {
+	switch s {
+	case Low:
+		return "low"
+	case Medium:
+		return "medium"
+	case High:
+		return "high"
+	case Critical:
+		return "critical"
+	}
+	return "unknown"
+}
+
+// ParseSeverity parses a severity name, the names used by GitHub advisories and
+// distributions are mapped to the closest severity.
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "unknown", "":
+		return Unknown, nil
+	case "low", "negligible", "unimportant":
+		return Low, nil
+	case "medium", "moderate":
+		return Medium, nil
+	case "high", "important":
+		return High, nil
+	case "critical":
+		return Critical, nil
+	}
+	return Unknown, fmt.Errorf("unknown severity %q, expected one of low, medium, high or critical", s)
+}
+
+// severity returns the severity of the vulnerability for the affected package.
+// Severities recorded by the database take precedence over the ones computed
+// from CVSS v3 vectors.
+func (v *Vulnerability) severity(affected Affected) Severity {
+	for _, s := range []string{affected.EcosystemSpecific.Severity, affected.DatabaseSpecific.Severity, v.DatabaseSpecific.Severity} {
+		if sev, err := ParseSeverity(s); err == nil && sev != Unknown {
+			return sev
+		}
+	}
+
+	sev := Unknown
+	for _, score := range v.Severity {
+		switch score.Type {
+		case "CVSS_V3":
+			if base, err := cvss3BaseScore(score.Score); err == nil {
+				sev = max(sev, cvssRating(base))
+			}
+		case "Ubuntu":
+			if s, err := ParseSeverity(score.Score); err == nil {
+				sev = max(sev, s)
+			}
+		}
+	}
+	return sev
+}
+
+// cvssRating maps a CVSS base score to its qualitative rating.
+func cvssRating(score float64) Severity {
+	switch {
+	case score >= 9:
+		return Critical
+	case score >= 7:
+		return High
+	case score >= 4:
+		return Medium
+	case score > 0:
+		return Low
+	}
+	return Unknown
+}
+
+// cvss3Weights are the weights of the CVSS v3 base metrics.
+var cvss3Weights = map[string]map[string]float64{
+	"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC": {"L": 0.77, "H": 0.44},
+	"UI": {"N": 0.85, "R": 0.62},
+	"C":  {"H": 0.56, "L": 0.22, "N": 0},
+	"I":  {"H": 0.56, "L": 0.22, "N": 0},
+	"A":  {"H": 0.56, "L": 0.22, "N": 0},
+}
+
+// cvss3BaseScore computes the base score of a CVSS v3.0 or v3.1 vector.
+// See https://www.first.org/cvss/v3.1/specification-document
+func cvss3BaseScore(vector string) (float64, error) {
+	metrics := map[string]string{}
+	parts := strings.Split(vector, "/")
+	if len(parts) == 0 || !strings.HasPrefix(parts[0], "CVSS:3") {
+		return 0, fmt.Errorf("unsupported CVSS vector %q", vector)
+	}
+	for _, part := range parts[1:] {
+		key, value, ok := strings.Cut(part, ":")
+		if !ok {
+			return 0, fmt.Errorf("invalid CVSS vector %q", vector)
+		}
+		metrics[key] = value
+	}
+
+	weight := func(metric string) (float64, error) {
+		w, ok := cvss3Weights[metric][metrics[metric]]
+		if !ok {
+			return 0, fmt.Errorf("invalid %s metric in CVSS vector %q", metric, vector)
+		}
+		return w, nil
+	}
+
+	scopeChanged := metrics["S"] == "C"
+	if metrics["S"] != "C" && metrics["S"] != "U" {
+		return 0, fmt.Errorf("invalid S metric in CVSS vector %q", vector)
+	}
+
+	var pr float64
+	switch metrics["PR"] {
+	case "N":
+		pr = 0.85
+	case "L":
+		pr = 0.62
+		if scopeChanged {
+			pr = 0.68
+		}
+	case "H":
+		pr = 0.27
+		if scopeChanged {
+			pr = 0.5
+		}
+	default:
+		return 0, fmt.Errorf("invalid PR metric in CVSS vector %q", vector)
+	}
+
+	w := map[string]float64{}
+	for _, metric := range []string{"AV", "AC", "UI", "C", "I", "A"} {
+		v, err := weight(metric)
+		if err != nil {
+			return 0, err
+		}
+		w[metric] = v
+	}
+
+	iss := 1 - (1-w["C"])*(1-w["I"])*(1-w["A"])
+	impact := 6.42 * iss
+	if scopeChanged {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, nil
+	}
+
+	exploitability := 8.22 * w["AV"] * w["AC"] * pr * w["UI"]
+	if scopeChanged {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), nil
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), nil
+}
+
+// roundUp rounds up to one decimal as defined in the CVSS v3.1 specification,
+// working on integers to avoid floating point errors.
+func roundUp(x float64) float64 {
+	i := int(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return float64(i/10000+1) / 10
+}
@@ -0,0 +1,175 @@
+package vulndb
+
+import (
+	"strconv"
+	"strings"
+)
+
+// compareVersions compares two versions of a package of the given ecosystem and
+// returns -1, 0 or 1. OS package versions are compared the way dpkg does, which
+// is close enough for apk and rpm versions. Language package versions are
+// compared segment by segment with pre-releases ordered before releases.
+func compareVersions(ecosystem, a, b string) int {
+	switch ecosystem {
+	case "Debian", "Ubuntu", "Alpine", "Red Hat", "Rocky Linux", "AlmaLinux", "SUSE", "openSUSE":
+		return compareDebian(a, b)
+	}
+	return compareRelease(a, b)
+}
+
+// compareDebian compares versions of the form [epoch:]upstream[-revision].
+func compareDebian(a, b string) int {
+	epochA, restA := splitEpoch(a)
+	epochB, restB := splitEpoch(b)
+	if epochA != epochB {
+		return cmpInt(epochA, epochB)
+	}
+
+	upstreamA, revisionA := splitRevision(restA)
+	upstreamB, revisionB := splitRevision(restB)
+	if c := compareSegments(upstreamA, upstreamB); c != 0 {
+		return c
+	}
+	return compareSegments(revisionA, revisionB)
+}
+
+func splitEpoch(v string) (int, string) {
+	if epoch, rest, ok := strings.Cut(v, ":"); ok {
+		if n, err := strconv.Atoi(epoch); err == nil {
+			return n, rest
+		}
+	}
+	return 0, v
+}
+
+func splitRevision(v string) (string, string) {
+	if i := strings.LastIndex(v, "-"); i != -1 {
+		return v[:i], v[i+1:]
+	}
+	return v, ""
+}
+
+// compareRelease compares semver and PEP 440 style versions. The version is
+// split into its release and pre-release parts, a release without a pre-release
+// is greater than the same release with one.
+func compareRelease(a, b string) int {
+	releaseA, preA := splitPrerelease(a)
+	releaseB, preB := splitPrerelease(b)
+	if c := compareSegments(releaseA, releaseB); c != 0 {
+		return c
+	}
+
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return compareSegments(preA, preB)
+}
+
+// splitPrerelease drops the `v` prefix and build metadata, and splits the
+// pre-release part of the version: either after a dash (semver) or from the
+// first letter (PEP 440, e.g. 1.0rc1). Post releases are part of the release.
+func splitPrerelease(v string) (string, string) {
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "+")
+
+	if release, pre, ok := strings.Cut(v, "-"); ok {
+		return release, pre
+	}
+	for i, r := range v {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			if strings.HasPrefix(strings.ToLower(v[i:]), "post") {
+				return v, ""
+			}
+			return strings.TrimRight(v[:i], "."), v[i:]
+		}
+	}
+	return v, ""
+}
+
+// compareSegments implements the dpkg comparison algorithm: versions are split
+// into alternating non-digit and digit runs, non-digit runs are compared
+// lexically with letters sorting before other characters and `~` before
+// anything, digit runs are compared numerically.
+func compareSegments(a, b string) int {
+	for a != "" || b != "" {
+		var nonDigitA, nonDigitB string
+		nonDigitA, a = splitRun(a, false)
+		nonDigitB, b = splitRun(b, false)
+		if c := compareNonDigits(nonDigitA, nonDigitB); c != 0 {
+			return c
+		}
+
+		var digitA, digitB string
+		digitA, a = splitRun(a, true)
+		digitB, b = splitRun(b, true)
+		if c := compareDigits(digitA, digitB); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func splitRun(s string, digits bool) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) == digits {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func compareNonDigits(a, b string) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var ca, cb byte
+		if i < len(a) {
+			ca = a[i]
+		}
+		if i < len(b) {
+			cb = b[i]
+		}
+		if oa, ob := order(ca), order(cb); oa != ob {
+			return cmpInt(oa, ob)
+		}
+	}
+	return 0
+}
+
+// order returns the sort weight of a character as per dpkg.
+func order(c byte) int {
+	switch {
+	case c == 0:
+		return 0
+	case c == '~':
+		return -1
+	case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		return int(c)
+	}
+	return int(c) + 256
+}
+
+func compareDigits(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return cmpInt(len(a), len(b))
+	}
+	return strings.Compare(a, b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,65 @@
+package vulndb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		ecosystem string
+		a, b      string
+		expected  int
+	}{
+		{ecosystem: "Debian", a: "2.36-9+deb12u4", b: "2.36-9+deb12u4", expected: 0},
+		{ecosystem: "Debian", a: "2.36-9+deb12u3", b: "2.36-9+deb12u4", expected: -1},
+		{ecosystem: "Debian", a: "1:1.0-1", b: "2.0-1", expected: 1},
+		{ecosystem: "Debian", a: "1.0~rc1-1", b: "1.0-1", expected: -1},
+		{ecosystem: "Debian", a: "1.10-1", b: "1.9-1", expected: 1},
+		{ecosystem: "Alpine", a: "1.2.4-r2", b: "1.2.4-r10", expected: -1},
+		{ecosystem: "npm", a: "4.18.2", b: "4.18.10", expected: -1},
+		{ecosystem: "npm", a: "1.0.0-rc.1", b: "1.0.0", expected: -1},
+		{ecosystem: "npm", a: "1.0.0-rc.2", b: "1.0.0-rc.1", expected: 1},
+		{ecosystem: "Go", a: "v0.18.0", b: "0.17.0", expected: 1},
+		{ecosystem: "Go", a: "v1.6.0+incompatible", b: "1.6.0", expected: 0},
+		{ecosystem: "PyPI", a: "2.0rc1", b: "2.0", expected: -1},
+		{ecosystem: "PyPI", a: "2.0.post1", b: "2.0", expected: 1},
+		{ecosystem: "crates.io", a: "1.0.197", b: "1.0.197", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ecosystem+"/"+tt.a+"/"+tt.b, func(t *testing.T) {
+			require.Equal(t, tt.expected, compareVersions(tt.ecosystem, tt.a, tt.b))
+			require.Equal(t, -tt.expected, compareVersions(tt.ecosystem, tt.b, tt.a))
+		})
+	}
+}
+
+func TestCVSS3BaseScore(t *testing.T) {
+	tests := []struct {
+		vector   string
+		expected float64
+		expError bool
+	}{
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", expected: 9.8},
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", expected: 10},
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", expected: 6.1},
+		{vector: "CVSS:3.0/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:N/A:N", expected: 5.5},
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", expected: 0},
+		{vector: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", expError: true},
+		{vector: "CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", expError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.vector, func(t *testing.T) {
+			score, err := cvss3BaseScore(tt.vector)
+			if tt.expError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, score)
+		})
+	}
+}